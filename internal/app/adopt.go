@@ -0,0 +1,277 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tforge/internal/cli"
+	"tforge/internal/config"
+	"tforge/internal/generate"
+	"tforge/internal/journal"
+	"tforge/internal/names"
+	"tforge/internal/snapshot"
+)
+
+func runAdopt(_ context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("adopt", flag.ContinueOnError)
+	fs.SetOutput(out)
+	name := fs.String("name", "", "save the script under this name (default: script file name)")
+	all := fs.Bool("all", false, "adopt every saved layout in ~/.tforge/sessions missing from the journal")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(config.SettingsPath(home))
+	if err != nil {
+		cli.Warn(out, "using default settings: %v", err)
+	}
+
+	scripts := fs.Args()
+	switch {
+	case *all && (len(scripts) > 0 || *name != ""):
+		return errors.New("--all cannot be combined with scripts or --name")
+	case *all:
+		return adoptAll(home, settings, out)
+	case len(scripts) == 0:
+		return errors.New("no scripts given; pass script paths or --all")
+	case len(scripts) > 1 && *name != "":
+		return errors.New("--name can only be used with a single script")
+	}
+
+	failed := 0
+	for _, path := range scripts {
+		saveName := *name
+		if saveName == "" {
+			saveName = names.Slug(strings.TrimSuffix(filepath.Base(path), ".sh"))
+		} else if err := names.Validate(saveName); err != nil {
+			return fmt.Errorf("invalid --name: %w (try %q)", err, names.Slug(saveName))
+		}
+		if err := adoptScript(home, saveName, path, settings, out); err != nil {
+			cli.Warn(out, "unable to adopt %s: %v", path, err)
+			failed++
+			continue
+		}
+		cli.Info(out, "Adopted %s as %s.", path, saveName)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d script(s) could not be adopted", failed, len(scripts))
+	}
+	return nil
+}
+
+// adoptScript parses a script produced by generate.Script and records it as a
+// new generation of name, dated by the script's modification time.
+func adoptScript(home, name, path string, settings config.Settings, out io.Writer) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	s, err := generate.Parse(string(b))
+	if err != nil {
+		return err
+	}
+	doc, gen, scriptPath, err := saveLayout(home, name, s, info.ModTime().UTC(), settings.OnConflict, out)
+	if err != nil {
+		return err
+	}
+	return updateJournal(home, name, doc, scriptPath, gen, settings.Retention, out)
+}
+
+// adoptAll registers every saved layout in ~/.tforge/sessions the journal
+// has lost track of. Layouts with generation snapshots get their history
+// back; scripts without any are parsed instead.
+func adoptAll(home string, settings config.Settings, out io.Writer) error {
+	data, err := journal.Load(journal.Path(home))
+	if err != nil {
+		return err
+	}
+	orphans, err := unadopted(filepath.Join(home, ".tforge", "sessions"), data)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		cli.Info(out, "Every saved layout is already in the journal.")
+		return nil
+	}
+	failed := 0
+	for _, o := range orphans {
+		if len(o.snapshots) > 0 {
+			n, err := adoptGenerations(home, o.name, o.snapshots, settings, out)
+			if err == nil {
+				cli.Info(out, "Adopted %s with %d generation(s).", o.name, n)
+				continue
+			}
+			if o.script == "" {
+				cli.Warn(out, "unable to adopt %s: %v", o.name, err)
+				failed++
+				continue
+			}
+			cli.Warn(out, "%s: %v; falling back to %s", o.name, err, o.script)
+		}
+		if err := adoptScript(home, o.name, o.script, settings, out); err != nil {
+			cli.Warn(out, "unable to adopt %s: %v", o.script, err)
+			failed++
+			continue
+		}
+		cli.Info(out, "Adopted %s as %s.", o.script, o.name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d saved layout(s) could not be adopted", failed, len(orphans))
+	}
+	return nil
+}
+
+// adoptGenerations records the snapshots of name, which saveLayout wrote
+// under sessions/<name>/generations, as its history with the newest as the
+// latest capture, and regenerates the scripts from them. Unreadable
+// snapshots are skipped. It returns the number of generations recorded.
+func adoptGenerations(home, name string, paths []string, settings config.Settings, out io.Writer) (int, error) {
+	type capture struct {
+		path string
+		doc  snapshot.Document
+	}
+	var captures []capture
+	for _, path := range paths {
+		doc, err := snapshot.ReadDocument(path)
+		if err != nil {
+			cli.Warn(out, "skipping unreadable snapshot: %v", err)
+			continue
+		}
+		captures = append(captures, capture{path, doc})
+	}
+	if len(captures) == 0 {
+		return 0, errors.New("no readable snapshots")
+	}
+	sort.SliceStable(captures, func(i, j int) bool {
+		return captures[i].doc.CapturedAt.Before(captures[j].doc.CapturedAt)
+	})
+
+	gens := make([]journal.Generation, len(captures))
+	for i, c := range captures {
+		dir := filepath.Dir(c.path)
+		gens[i] = journal.Generation{
+			ID:           i + 1,
+			Dir:          dir,
+			ScriptPath:   filepath.Join(dir, "script.sh"),
+			SnapshotPath: c.path,
+			Windows:      len(c.doc.Session.Windows),
+			CapturedAt:   c.doc.CapturedAt,
+		}
+		for _, w := range c.doc.Session.Windows {
+			gens[i].Panes += len(w.Panes)
+		}
+		if !exists(gens[i].ScriptPath) {
+			if err := writeScript(gens[i].ScriptPath, c.doc.Session, settings.OnConflict); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	release, err := lockState(home)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	path := journal.Path(home)
+	data, err := journal.Load(path)
+	if err != nil {
+		return 0, err
+	}
+	if journal.Find(data, name) != nil {
+		return 0, fmt.Errorf("%s is already in the journal", name)
+	}
+	latest, doc := gens[len(gens)-1], captures[len(captures)-1].doc
+	scriptPath := filepath.Join(home, ".tforge", "sessions", name+".sh")
+	if err := writeScript(scriptPath, doc.Session, settings.OnConflict); err != nil {
+		return 0, err
+	}
+	cli.Info(out, "Wrote script: %s", scriptPath)
+	data = journal.Upsert(data, journal.Entry{
+		Name:         name,
+		Session:      doc.Session.Name,
+		ScriptPath:   scriptPath,
+		SnapshotPath: latest.SnapshotPath,
+		Windows:      latest.Windows,
+		Panes:        latest.Panes,
+		CapturedAt:   latest.CapturedAt,
+		Generations:  gens,
+	})
+	return len(gens), journal.Save(path, data)
+}
+
+// orphan is a saved layout in the sessions directory that no journal entry
+// refers to: its top-level script, its generation snapshots, or both.
+type orphan struct {
+	name      string
+	script    string
+	snapshots []string
+}
+
+// unadopted lists the saved layouts in dir missing from the journal, by name.
+func unadopted(dir string, data journal.Data) ([]orphan, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, e := range data.Entries {
+		known[e.ScriptPath] = true
+		for _, n := range journal.FileNames(e) {
+			known[n] = true
+			known[filepath.Join(dir, n+".sh")] = true
+		}
+	}
+	byName := map[string]*orphan{}
+	var out []*orphan
+	get := func(name string) *orphan {
+		if o := byName[name]; o != nil {
+			return o
+		}
+		o := &orphan{name: name}
+		byName[name] = o
+		out = append(out, o)
+		return o
+	}
+	for _, de := range entries {
+		path := filepath.Join(dir, de.Name())
+		switch {
+		case de.IsDir():
+			if known[de.Name()] || names.Validate(de.Name()) != nil {
+				continue
+			}
+			snapshots, err := filepath.Glob(filepath.Join(path, "generations", "*", "snapshot.json"))
+			if err != nil {
+				return nil, err
+			}
+			if len(snapshots) > 0 {
+				get(de.Name()).snapshots = snapshots
+			}
+		case strings.HasSuffix(de.Name(), ".sh"):
+			name := names.Slug(strings.TrimSuffix(de.Name(), ".sh"))
+			if known[path] || known[name] {
+				continue
+			}
+			get(name).script = path
+		}
+	}
+	orphans := make([]orphan, len(out))
+	for i, o := range out {
+		orphans[i] = *o
+	}
+	return orphans, nil
+}
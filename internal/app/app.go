@@ -17,6 +17,10 @@ import (
 	"tforge/internal/fsutil"
 	"tforge/internal/generate"
 	"tforge/internal/journal"
+	"tforge/internal/names"
+	"tforge/internal/onconflict"
+	"tforge/internal/restore"
+	"tforge/internal/shell"
 	"tforge/internal/snapshot"
 	"tforge/internal/tmux"
 )
@@ -41,6 +45,20 @@ func Run(ctx context.Context, args []string, in io.Reader, out io.Writer, _ io.W
 		return runCapture(ctx, args[1:], in, out)
 	case "restore":
 		return runRestore(ctx, args[1:], in, out)
+	case "list", "ls":
+		return runList(ctx, args[1:], out)
+	case "delete", "rm":
+		return runDelete(ctx, args[1:], in, out)
+	case "doctor":
+		return runDoctor(ctx, args[1:], out)
+	case "adopt":
+		return runAdopt(ctx, args[1:], out)
+	case "import":
+		return runImport(ctx, args[1:], in, out)
+	case "export":
+		return runExport(ctx, args[1:], in, out)
+	case "show":
+		return runShow(ctx, args[1:], in, out)
 	default:
 		return usageError(out, fmt.Sprintf("unknown command %q", args[0]))
 	}
@@ -54,10 +72,17 @@ func runCapture(ctx context.Context, args []string, in io.Reader, out io.Writer)
 	saveName := fs.String("name", "", "name to save generated script as")
 	bindKey := fs.String("key", "", "tmux key to bind (prefix + key), empty to skip")
 	noBind := fs.Bool("no-bind", false, "do not modify ~/.tmux.conf")
+	noCommands := fs.Bool("no-commands", false, "do not record commands running in panes")
+	withContents := fs.Bool("with-contents", false, "save pane scrollback and replay it on restore")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *bindKey != "" && !*noBind {
+		if err := config.ValidateKey(*bindKey); err != nil {
+			return fmt.Errorf("invalid --key: %w", err)
+		}
+	}
 
 	runner := tmux.NewCommandRunner()
 	service := tmux.NewService(runner)
@@ -81,14 +106,18 @@ func runCapture(ctx context.Context, args []string, in io.Reader, out io.Writer)
 	}
 
 	if *saveName == "" {
-		v, err := prompt.AskDefault("Save layout as", *sessionName)
+		v, err := prompt.AskValid("Save layout as", names.Slug(*sessionName), names.Validate, names.Slug)
 		if err != nil {
 			return err
 		}
 		*saveName = v
+	} else if err := names.Validate(*saveName); err != nil {
+		return fmt.Errorf("invalid --name: %w (try %q)", err, names.Slug(*saveName))
 	}
 
 	capturer := snapshot.NewCapturer(service)
+	capturer.SkipCommands = *noCommands
+	capturer.Contents = *withContents
 	snap, err := capturer.CaptureSession(ctx, *sessionName)
 	if err != nil {
 		return err
@@ -98,17 +127,17 @@ func runCapture(ctx context.Context, args []string, in io.Reader, out io.Writer)
 	if err != nil {
 		return err
 	}
-	scriptPath := filepath.Join(home, ".tforge", "sessions", *saveName+".sh")
-	content, err := generate.Script(snap)
+	settings, err := config.LoadSettings(config.SettingsPath(home))
 	if err != nil {
-		return err
+		cli.Warn(out, "using default settings: %v", err)
 	}
-	if err := fsutil.WriteExecutable(scriptPath, []byte(content)); err != nil {
+
+	doc, gen, scriptPath, err := saveLayout(home, *saveName, snap, time.Now().UTC(), settings.OnConflict, out)
+	if err != nil {
 		return err
 	}
-	cli.Info(out, "Wrote script: %s", scriptPath)
 
-	if err := updateJournal(home, snap, scriptPath); err != nil {
+	if err := updateJournal(home, *saveName, doc, scriptPath, gen, settings.Retention, out); err != nil {
 		cli.Warn(out, "unable to update journal: %v", err)
 	}
 
@@ -127,11 +156,19 @@ func runCapture(ctx context.Context, args []string, in io.Reader, out io.Writer)
 			}
 		}
 		if strings.TrimSpace(*bindKey) != "" {
+			if err := config.ValidateKey(*bindKey); err != nil {
+				return err
+			}
 			if warning := config.CommonKeyWarning(*bindKey); warning != "" {
 				cli.Warn(out, "%s", warning)
 			}
 			tmuxConf := filepath.Join(home, ".tmux.conf")
+			release, err := lockState(home)
+			if err != nil {
+				return err
+			}
 			updated, changed, err := config.UpdateFile(tmuxConf, *saveName, *bindKey, scriptPath)
+			release()
 			if err != nil {
 				return err
 			}
@@ -160,7 +197,11 @@ func runCapture(ctx context.Context, args []string, in io.Reader, out io.Writer)
 func runRestore(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
 	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
 	fs.SetOutput(out)
-	sessionName := fs.String("session", "", "session name from journal")
+	name := fs.String("name", "", "saved layout name from journal")
+	fs.StringVar(name, "session", "", "alias for --name")
+	at := fs.String("at", "", "generation number or time to restore (default: latest)")
+	dryRun := fs.Bool("dry-run", false, "print the tmux commands restore would run, without running them")
+	onConflict := fs.String("on-conflict", "", "when the session is running: attach, replace, rename, merge or fail (default from config)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -169,6 +210,16 @@ func runRestore(ctx context.Context, args []string, in io.Reader, out io.Writer)
 	if err != nil {
 		return err
 	}
+	settings, err := config.LoadSettings(config.SettingsPath(home))
+	if err != nil {
+		cli.Warn(out, "using default settings: %v", err)
+	}
+	conflict := settings.OnConflict
+	if *onConflict != "" {
+		if conflict, err = onconflict.Parse(*onConflict); err != nil {
+			return fmt.Errorf("invalid --on-conflict: %w", err)
+		}
+	}
 	jPath := journal.Path(home)
 	data, err := journal.Load(jPath)
 	if err != nil {
@@ -179,38 +230,63 @@ func runRestore(ctx context.Context, args []string, in io.Reader, out io.Writer)
 	}
 
 	prompt := cli.NewPrompter(in, out)
-	if *sessionName == "" {
-		opts := make([]cli.Option, 0, len(data.Entries))
-		for _, e := range data.Entries {
-			opts = append(opts, cli.Option{
-				ID:      e.Session,
-				Label:   e.Session,
-				Details: fmt.Sprintf("windows=%d panes=%d captured=%s", e.Windows, e.Panes, e.CapturedAt.Format(time.RFC3339)),
-			})
-		}
-		sel, ok, err := cli.SelectFuzzy(prompt, out, "Select a saved session to restore", opts)
+	if *name == "" {
+		sel, ok, err := selectEntry(prompt, out, data, "Select a saved session to restore")
 		if err != nil {
 			return err
 		}
 		if !ok {
 			return errors.New("restore cancelled")
 		}
-		*sessionName = sel
+		*name = sel
 	}
 
-	var entry *journal.Entry
-	for i := range data.Entries {
-		if data.Entries[i].Session == *sessionName {
-			entry = &data.Entries[i]
-			break
-		}
+	if err := names.Validate(*name); err != nil {
+		return fmt.Errorf("invalid --name: %w", err)
 	}
+	entry := journal.Find(data, *name)
 	if entry == nil {
-		return fmt.Errorf("session %q is not in journal %s", *sessionName, jPath)
+		return fmt.Errorf("saved layout %q is not in journal %s", *name, jPath)
+	}
+	if *dryRun {
+		s, err := loadSession(*entry, *at)
+		if err != nil {
+			return err
+		}
+		return restoreDryRun(ctx, s, conflict, out)
+	}
+	if *at != "" {
+		gen, err := journal.FindGeneration(*entry, *at)
+		if err != nil {
+			return err
+		}
+		cli.Info(out, "Restoring %s generation %d from %s (windows=%d, panes=%d)", entry.Name, gen.ID, gen.CapturedAt.Format(time.RFC3339), gen.Windows, gen.Panes)
+		doc, err := snapshot.ReadDocument(gen.SnapshotPath)
+		if err != nil {
+			return err
+		}
+		if gen.ScriptPath != "" {
+			if err := writeScript(gen.ScriptPath, doc.Session, conflict); err != nil {
+				return fmt.Errorf("regenerate script from %s: %w", gen.SnapshotPath, err)
+			}
+		}
+		return restoreNative(ctx, doc.Session, conflict, out)
+	}
+	cli.Info(out, "Restoring %s (windows=%d, panes=%d)", entry.Name, entry.Windows, entry.Panes)
+
+	if entry.SnapshotPath != "" {
+		doc, err := snapshot.ReadDocument(entry.SnapshotPath)
+		if err == nil {
+			if err := writeScript(entry.ScriptPath, doc.Session, conflict); err != nil {
+				return fmt.Errorf("regenerate script from %s: %w", entry.SnapshotPath, err)
+			}
+			return restoreNative(ctx, doc.Session, conflict, out)
+		}
+		cli.Warn(out, "using existing script; unable to read snapshot: %v", err)
 	}
-	cli.Info(out, "Restoring %s (windows=%d, panes=%d)", entry.Session, entry.Windows, entry.Panes)
 
 	cmd := exec.CommandContext(ctx, "/usr/bin/env", "bash", entry.ScriptPath)
+	cmd.Env = append(os.Environ(), "TFORGE_ON_CONFLICT="+string(conflict))
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -220,24 +296,203 @@ func runRestore(ctx context.Context, args []string, in io.Reader, out io.Writer)
 	return nil
 }
 
-func updateJournal(home string, snap snapshot.Session, scriptPath string) error {
+func selectEntry(prompt *cli.Prompter, out io.Writer, data journal.Data, title string) (string, bool, error) {
+	home, _ := os.UserHomeDir()
+	opts := make([]cli.Option, 0, len(data.Entries))
+	for _, e := range data.Entries {
+		opts = append(opts, cli.Option{
+			ID:      e.Name,
+			Label:   e.Name,
+			Details: fmt.Sprintf("session=%s windows=%d panes=%d captured=%s", e.Session, e.Windows, e.Panes, e.CapturedAt.Format(time.RFC3339)),
+			Preview: func(width int) []string {
+				s, err := loadSession(e, "")
+				if err != nil {
+					return []string{fmt.Sprintf("no preview: %v", err)}
+				}
+				return generate.Preview(s, width, home)
+			},
+		})
+	}
+	return cli.SelectFuzzy(prompt, out, title, opts)
+}
+
+// restoreDryRun prints what restoreNative would do for s, in order, as tmux
+// command lines. Only read-only tmux queries are run.
+func restoreDryRun(ctx context.Context, s snapshot.Session, conflict onconflict.Strategy, out io.Writer) error {
+	rec := restore.NewRecorder(tmux.NewService(tmux.NewCommandRunner()))
+	engine := restore.NewEngine(rec)
+	engine.OnConflict = conflict
+	res, err := engine.Restore(ctx, s)
+	if err != nil {
+		return fmt.Errorf("restore %s: %w", s.Name, err)
+	}
+	cli.Info(out, "Dry run: restoring %s (--on-conflict=%s) would run:", s.Name, conflict)
+	for _, step := range rec.Steps {
+		fmt.Fprintln(out, step)
+	}
+	switch {
+	case res.Created && res.Session != s.Name:
+		fmt.Fprintf(out, "# restoring as %s instead\n", res.Session)
+	case !res.Created && len(res.Added) > 0:
+		fmt.Fprintf(out, "# adding window(s) %s to the existing session\n", strings.Join(res.Added, ", "))
+	case !res.Created:
+		fmt.Fprintf(out, "# keeping the existing session %s as it is\n", res.Session)
+	}
+	if os.Getenv("TMUX") != "" {
+		fmt.Fprintf(out, "tmux switch-client -t %s\n", shell.Quote(res.Session))
+	} else {
+		fmt.Fprintf(out, "tmux attach-session -t %s\n", shell.Quote(res.Session))
+	}
+	return nil
+}
+
+func restoreNative(ctx context.Context, s snapshot.Session, conflict onconflict.Strategy, out io.Writer) error {
+	service := tmux.NewService(tmux.NewCommandRunner())
+	engine := restore.NewEngine(service)
+	engine.OnConflict = conflict
+	res, err := engine.Restore(ctx, s)
+	if err != nil {
+		return fmt.Errorf("restore %s: %w", s.Name, err)
+	}
+	switch {
+	case res.Created && res.Session != s.Name:
+		cli.Info(out, "Session %s already exists; restored as %s.", s.Name, res.Session)
+	case !res.Created && len(res.Added) > 0:
+		cli.Info(out, "Added window(s) %s to the running session %s.", strings.Join(res.Added, ", "), s.Name)
+	case !res.Created:
+		cli.Info(out, "Session %s already exists; attaching to it.", s.Name)
+	}
+	return service.Attach(ctx, res.Session)
+}
+
+// saveLayout records s as a new generation of the saved layout name and
+// refreshes its top-level script. The caller updates the journal.
+func saveLayout(home, name string, s snapshot.Session, capturedAt time.Time, onConflict onconflict.Strategy, out io.Writer) (snapshot.Document, journal.Generation, string, error) {
+	sessionsDir := filepath.Join(home, ".tforge", "sessions")
+	scriptPath := filepath.Join(sessionsDir, name+".sh")
+	genDir, err := newGenerationDir(filepath.Join(sessionsDir, name, "generations"), capturedAt)
+	if err != nil {
+		return snapshot.Document{}, journal.Generation{}, "", err
+	}
+	if err := snapshot.SaveContents(filepath.Join(genDir, "contents"), &s); err != nil {
+		return snapshot.Document{}, journal.Generation{}, "", err
+	}
+	doc := snapshot.NewDocument(s, capturedAt)
+	gen := journal.Generation{
+		Dir:          genDir,
+		ScriptPath:   filepath.Join(genDir, "script.sh"),
+		SnapshotPath: filepath.Join(genDir, "snapshot.json"),
+	}
+	if err := snapshot.WriteDocument(gen.SnapshotPath, doc); err != nil {
+		return snapshot.Document{}, journal.Generation{}, "", err
+	}
+	cli.Info(out, "Wrote snapshot: %s", gen.SnapshotPath)
+	if err := writeScript(gen.ScriptPath, doc.Session, onConflict); err != nil {
+		return snapshot.Document{}, journal.Generation{}, "", err
+	}
+	if err := writeScript(scriptPath, doc.Session, onConflict); err != nil {
+		return snapshot.Document{}, journal.Generation{}, "", err
+	}
+	cli.Info(out, "Wrote script: %s", scriptPath)
+	return doc, gen, scriptPath, nil
+}
+
+func writeScript(path string, s snapshot.Session, onConflict onconflict.Strategy) error {
+	content, err := generate.Script(s, onConflict)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteExecutable(path, []byte(content))
+}
+
+// lockTimeout bounds how long a command waits for another tforge process
+// (e.g. an autosave binding) to finish updating shared state.
+const lockTimeout = 10 * time.Second
+
+// lockState serialises read-modify-write cycles on the journal and
+// ~/.tmux.conf across concurrent tforge invocations.
+func lockState(home string) (func() error, error) {
+	return fsutil.Lock(filepath.Join(filepath.Dir(journal.Path(home)), "lock"), lockTimeout)
+}
+
+func updateJournal(home, name string, doc snapshot.Document, scriptPath string, gen journal.Generation, retention config.Retention, out io.Writer) error {
+	release, err := lockState(home)
+	if err != nil {
+		return err
+	}
+	defer release()
 	path := journal.Path(home)
 	data, err := journal.Load(path)
 	if err != nil {
 		return err
 	}
-	panes := 0
-	for _, w := range snap.Windows {
-		panes += len(w.Panes)
+	var history []journal.Generation
+	var legacyName string
+	gen.ID = 1
+	if e := journal.Find(data, name); e != nil {
+		history = journal.History(*e)
+		gen.ID = journal.NextGenerationID(*e)
+		legacyName = e.LegacyName
+	}
+	gen.Windows = len(doc.Session.Windows)
+	for _, w := range doc.Session.Windows {
+		gen.Panes += len(w.Panes)
 	}
+	gen.CapturedAt = doc.CapturedAt
+	kept, pruned := journal.Prune(append(history, gen), retention.KeepGenerations, retention.MaxAge(), doc.CapturedAt)
+
 	data = journal.Upsert(data, journal.Entry{
-		Session:    snap.Name,
-		ScriptPath: scriptPath,
-		Windows:    len(snap.Windows),
-		Panes:      panes,
-		CapturedAt: time.Now().UTC(),
+		Name:         name,
+		Session:      doc.Session.Name,
+		ScriptPath:   scriptPath,
+		SnapshotPath: gen.SnapshotPath,
+		Windows:      gen.Windows,
+		Panes:        gen.Panes,
+		CapturedAt:   gen.CapturedAt,
+		Generations:  kept,
+		LegacyName:   legacyName,
 	})
-	return journal.Save(path, data)
+	if err := journal.Save(path, data); err != nil {
+		return err
+	}
+	for _, g := range pruned {
+		if err := removeGeneration(g); err != nil {
+			cli.Warn(out, "unable to remove generation %d: %v", g.ID, err)
+		}
+	}
+	if len(pruned) > 0 {
+		cli.Info(out, "Pruned %d old generation(s) of %s.", len(pruned), name)
+	}
+	return nil
+}
+
+// newGenerationDir creates a fresh, timestamped directory for one capture.
+func newGenerationDir(parent string, now time.Time) (string, error) {
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(parent, now.Format("20060102T150405Z"))
+	dir := base
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		dir = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func removeGeneration(g journal.Generation) error {
+	if g.Dir != "" {
+		return os.RemoveAll(g.Dir)
+	}
+	if err := os.Remove(g.SnapshotPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
 func selectTmuxSession(ctx context.Context, service *tmux.Service, prompt *cli.Prompter, out io.Writer) (string, bool, error) {
@@ -267,25 +522,90 @@ func printHelp(out io.Writer) {
 Usage:
   %s capture [flags]
   %s restore [flags]
+  %s list [--json]
+  %s delete [flags] [name]
+  %s doctor [--fix]
+  %s adopt [--name <name>] <script.sh>... | --all
+  %s import resurrect|tmuxp|tmuxinator [flags] [file|project]
+  %s export --format tmuxp|tmuxinator|zellij [flags] [name]
+  %s show [--at <gen|time>] [--width <n>] [name]
 
 Commands:
   capture     Capture a tmux session and generate a reusable script
   restore     Restore a captured session from ~/.tforge/journal.json
+  list        Show saved sessions, their scripts and whether they are running
+  delete, rm  Remove a saved session: journal entry, scripts and keybinding
+  doctor      Check tmux, the journal, saved scripts and keybindings for problems
+  adopt       Register scripts generated by tforge in the journal
+  import      Import tmux-resurrect saves (default: latest) or tmuxp and
+              tmuxinator project files
+  export      Print a saved layout as a tmuxp, tmuxinator or Zellij config
+  show        Draw the windows and panes of a saved layout
 
 Flags (capture):
   --session <name>   tmux session name to capture
-  --name <name>      output script name (default: same as session)
+  --name <name>      saved layout name (default: same as session); capturing
+                     one session under several names keeps each layout
   --key <key>        bind key (prefix + key), empty to skip
   --no-bind          skip updating ~/.tmux.conf
+  --no-commands      do not record or replay commands running in panes
+  --with-contents    save pane scrollback and replay it into restored panes
 
 Flags (restore):
-  --session <name>   restore a specific saved session (else fuzzy select)
+  --name <name>      restore a specific saved layout (else fuzzy select)
+  --session <name>   alias for --name
+  --at <gen|time>    restore an earlier generation by number or capture time
+  --dry-run          print the tmux commands restore would run and stop
+  --on-conflict <s>  when the session is already running: attach (default;
+                     a fresh 1-window, 1-pane session is replaced), replace,
+                     rename (restore as <name>-2), merge (add missing
+                     windows) or fail
+
+Flags (list):
+  --json             print JSON instead of a table
+
+Flags (delete):
+  --name <name>      delete a specific saved layout (else fuzzy select)
+  --yes              skip the confirmation prompt
+
+Flags (doctor):
+  --fix              repair what can be repaired (regenerate or drop missing
+                     scripts, remove orphaned files and stale keybindings)
+
+Flags (adopt):
+  --name <name>      save a single script under this name (default: its file name)
+  --all              rebuild the journal from every unregistered layout in
+                     ~/.tforge/sessions, keeping its saved generations
+
+Flags (import):
+  --session <name>   session to import from the file (else fuzzy select)
+  --name <name>      save the session under this name (default: session name)
+  --all              import every session in the file
+
+Flags (export):
+  --format <fmt>     tmuxp, tmuxinator or zellij (KDL layout)
+  --name <name>      saved layout to export (else fuzzy select)
+  --at <gen|time>    export an earlier generation
+  -o <file>          write to a file instead of stdout
+
+Flags (show):
+  --name <name>      saved layout to show (else fuzzy select)
+  --at <gen|time>    show an earlier generation
+  --width <n>        diagram width in columns (default 80)
 
 Examples:
   tf capture
   tforge capture --session hive --name hive --key g
   tforge restore
-`, cmd, cmd, cmd)
+  tforge list --json
+  tforge rm hive
+  tforge doctor --fix
+  tforge show hive
+  tforge adopt --all
+  tforge import resurrect --all
+  tforge import tmuxinator blog
+  tforge export --format tmuxp hive > hive.yaml
+`, cmd, cmd, cmd, cmd, cmd, cmd, cmd, cmd, cmd, cmd)
 }
 
 func usageError(out io.Writer, msg string) error {
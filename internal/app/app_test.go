@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tforge/internal/journal"
+	"tforge/internal/snapshot"
+)
+
+// fakeTmux puts a tmux on PATH that reports a running session hive with a
+// single window editor, creates new sessions as window @2 with pane %2 and
+// accepts every other command.
+func fakeTmux(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+list-sessions) printf 'hive|\n' ;;
+list-windows) printf '@1|0|editor|layout|1|\n' ;;
+new-session) printf '@2 %%2\n' ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(bin, "tmux"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "")
+}
+
+func TestRestoreRegeneratesScriptWithOnConflictFlag(t *testing.T) {
+	fakeTmux(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".tforge", "sessions", "hive")
+	s := snapshot.Session{Name: "hive", Windows: []snapshot.Window{{Name: "editor", Layout: "b25d,80x24,0,0,0", Panes: []snapshot.Pane{{Path: home}}}}}
+	capturedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	gen := journal.Generation{
+		ID:           1,
+		ScriptPath:   filepath.Join(dir, "generations", "1", "script.sh"),
+		SnapshotPath: filepath.Join(dir, "generations", "1", "snapshot.json"),
+		Windows:      1,
+		Panes:        1,
+		CapturedAt:   capturedAt,
+	}
+	if err := snapshot.WriteDocument(gen.SnapshotPath, snapshot.NewDocument(s, capturedAt)); err != nil {
+		t.Fatal(err)
+	}
+	entry := journal.Entry{
+		Name:         "hive",
+		Session:      "hive",
+		ScriptPath:   filepath.Join(home, ".tforge", "sessions", "hive.sh"),
+		SnapshotPath: gen.SnapshotPath,
+		Windows:      1,
+		Panes:        1,
+		CapturedAt:   capturedAt,
+		Generations:  []journal.Generation{gen},
+	}
+	if err := journal.Save(journal.Path(home), journal.Data{Entries: []journal.Entry{entry}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		args   []string
+		script string
+	}{
+		{[]string{"restore", "--name", "hive", "--on-conflict", "merge"}, entry.ScriptPath},
+		{[]string{"restore", "--name", "hive", "--at", "1", "--on-conflict", "rename"}, gen.ScriptPath},
+	} {
+		var out bytes.Buffer
+		if err := Run(context.Background(), tc.args, strings.NewReader(""), &out, &out); err != nil {
+			t.Fatalf("%v: %v\n%s", tc.args, err, out.String())
+		}
+		b, err := os.ReadFile(tc.script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `ON_CONFLICT="${TFORGE_ON_CONFLICT:-` + tc.args[len(tc.args)-1] + `}"`
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("%v: expected %s to contain %s", tc.args, tc.script, want)
+		}
+	}
+}
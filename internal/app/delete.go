@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"tforge/internal/cli"
+	"tforge/internal/config"
+	"tforge/internal/journal"
+	"tforge/internal/names"
+	"tforge/internal/tmux"
+)
+
+func runDelete(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	fs.SetOutput(out)
+	name := fs.String("name", "", "saved layout to delete (else fuzzy select)")
+	fs.StringVar(name, "session", "", "alias for --name")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" && fs.NArg() > 0 {
+		*name = fs.Arg(0)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	jPath := journal.Path(home)
+	data, err := journal.Load(jPath)
+	if err != nil {
+		return err
+	}
+	if len(data.Entries) == 0 {
+		return errors.New("no saved sessions found")
+	}
+
+	prompt := cli.NewPrompter(in, out)
+	if *name == "" {
+		sel, ok, err := selectEntry(prompt, out, data, "Select a saved session to delete")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("delete cancelled")
+		}
+		*name = sel
+	}
+	if err := names.Validate(*name); err != nil {
+		return fmt.Errorf("invalid --name: %w", err)
+	}
+	entry := journal.Find(data, *name)
+	if entry == nil {
+		return fmt.Errorf("saved layout %q is not in journal %s", *name, jPath)
+	}
+	if !*yes {
+		ok, err := prompt.AskYesNo(fmt.Sprintf("Delete %s and its %d generation(s)", entry.Name, len(journal.History(*entry))), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("delete cancelled")
+		}
+	}
+
+	release, err := lockState(home)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// Another invocation may have changed the journal while we were prompting.
+	if data, err = journal.Load(jPath); err != nil {
+		return err
+	}
+	if entry = journal.Find(data, *name); entry == nil {
+		return fmt.Errorf("saved layout %q is not in journal %s", *name, jPath)
+	}
+
+	var fileNames []string
+	for _, n := range journal.FileNames(*entry) {
+		// Never take files another saved layout is named after.
+		if other := journal.Find(data, n); other == nil || other.Name == entry.Name {
+			fileNames = append(fileNames, n)
+		}
+	}
+	for _, g := range journal.History(*entry) {
+		if err := removeGeneration(g); err != nil {
+			cli.Warn(out, "unable to remove generation %d: %v", g.ID, err)
+		}
+	}
+	sessionsDir := filepath.Join(home, ".tforge", "sessions")
+	for _, n := range fileNames {
+		if n == "" || n == "." || n == ".." || filepath.Base(n) != n {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(sessionsDir, n)); err != nil {
+			cli.Warn(out, "unable to remove saved data: %v", err)
+		}
+	}
+	scripts := []string{entry.ScriptPath}
+	for _, n := range fileNames[1:] {
+		// A capture since the journal was migrated writes the script under
+		// the new name; the one from before is still the entry's.
+		if legacy := filepath.Join(sessionsDir, n+".sh"); legacy != entry.ScriptPath {
+			scripts = append(scripts, legacy)
+		}
+	}
+	for _, script := range scripts {
+		if err := os.Remove(script); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		cli.Info(out, "Removed script: %s", script)
+	}
+
+	data, _ = journal.Remove(data, entry.Name)
+	if err := journal.Save(jPath, data); err != nil {
+		return err
+	}
+	cli.Info(out, "Removed %s from journal.", *name)
+
+	tmuxConf := filepath.Join(home, ".tmux.conf")
+	var keys []string
+	if content, err := os.ReadFile(tmuxConf); err == nil {
+		for _, b := range config.Bindings(string(content)) {
+			if slices.Contains(fileNames, b.Name) && b.Key != "" {
+				keys = append(keys, b.Key)
+			}
+		}
+	}
+	changed := false
+	for _, n := range fileNames {
+		for _, script := range scripts {
+			c, err := config.RemoveFile(tmuxConf, n, script)
+			if err != nil {
+				return err
+			}
+			changed = changed || c
+		}
+	}
+	if changed {
+		cli.Info(out, "Removed keybinding from %s", tmuxConf)
+		svc := tmux.NewService(tmux.NewCommandRunner())
+		for _, key := range keys {
+			if err := svc.UnbindKey(ctx, key); err != nil {
+				cli.Warn(out, "unable to unbind %s: %v", key, err)
+			}
+		}
+		if err := svc.ReloadConfig(ctx, tmuxConf); err != nil {
+			cli.Warn(out, "unable to reload tmux config automatically: %v", err)
+		} else {
+			cli.Info(out, "Reloaded tmux config.")
+		}
+	}
+	cli.Info(out, "Done.")
+	return nil
+}
@@ -0,0 +1,302 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tforge/internal/cli"
+	"tforge/internal/config"
+	"tforge/internal/journal"
+	"tforge/internal/snapshot"
+	"tforge/internal/tmux"
+)
+
+// tforge reads tmux state through the #{q:...} format modifier.
+const minTmuxMajor, minTmuxMinor = 2, 9
+
+// finding is one problem reported by doctor. fix is nil when tforge cannot
+// repair it itself; conf marks fixes that edit ~/.tmux.conf.
+type finding struct {
+	msg  string
+	fix  func() error
+	conf bool
+}
+
+func runDoctor(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fix := fs.Bool("fix", false, "repair the problems that can be repaired")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	if *fix {
+		release, err := lockState(home)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	service := tmux.NewService(tmux.NewCommandRunner())
+	findings := checkTmux(ctx, service)
+	state, err := checkState(home)
+	if err != nil {
+		return err
+	}
+	findings = append(findings, state...)
+
+	if len(findings) == 0 {
+		cli.Info(out, "No problems found.")
+		return nil
+	}
+	fixable, unfixed, reload := 0, 0, false
+	for _, f := range findings {
+		cli.Warn(out, "%s", f.msg)
+		if f.fix == nil {
+			unfixed++
+			continue
+		}
+		fixable++
+		if !*fix {
+			continue
+		}
+		if err := f.fix(); err != nil {
+			cli.Warn(out, "  unable to fix: %v", err)
+			unfixed++
+			continue
+		}
+		reload = reload || f.conf
+		cli.Info(out, "  fixed")
+	}
+	if reload {
+		if sessions, _ := service.ListSessions(ctx); len(sessions) > 0 {
+			if err := service.ReloadConfig(ctx, filepath.Join(home, ".tmux.conf")); err != nil {
+				cli.Warn(out, "unable to reload tmux config automatically: %v", err)
+			}
+		}
+	}
+	switch {
+	case !*fix && fixable > 0:
+		return fmt.Errorf("found %d problem(s); run 'tforge doctor --fix' to repair %d of them", len(findings), fixable)
+	case unfixed > 0:
+		return fmt.Errorf("%d problem(s) need manual attention", unfixed)
+	}
+	cli.Info(out, "Fixed %d problem(s).", fixable)
+	return nil
+}
+
+func checkTmux(ctx context.Context, service *tmux.Service) []finding {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return []finding{{msg: "tmux is not installed or not on PATH"}}
+	}
+	v, err := service.Version(ctx)
+	if err != nil {
+		return []finding{{msg: fmt.Sprintf("unable to determine tmux version: %v", err)}}
+	}
+	if !tmux.VersionAtLeast(v, minTmuxMajor, minTmuxMinor) {
+		return []finding{{msg: fmt.Sprintf("tmux %s is too old; tforge needs %d.%d or newer", v, minTmuxMajor, minTmuxMinor)}}
+	}
+	return nil
+}
+
+// checkState cross-checks the journal, the sessions directory and the
+// keybindings in ~/.tmux.conf.
+func checkState(home string) ([]finding, error) {
+	jPath := journal.Path(home)
+	data, err := journal.Load(jPath)
+	if err != nil {
+		return nil, err
+	}
+	sessionsDir := filepath.Join(filepath.Dir(jPath), "sessions")
+	tmuxConf := filepath.Join(home, ".tmux.conf")
+	settings, settingsErr := config.LoadSettings(config.SettingsPath(home))
+
+	var findings []finding
+	if settingsErr != nil {
+		findings = append(findings, finding{msg: fmt.Sprintf("%v; defaults are used instead", settingsErr)})
+	}
+	// saveJournal persists data after a fix has changed it; fixes run in
+	// order, so each one sees the changes made by earlier ones.
+	saveJournal := func() error { return journal.Save(jPath, data) }
+
+	knownNames, knownScripts := map[string]bool{}, map[string]bool{}
+	for _, e := range data.Entries {
+		name := e.Name
+		knownScripts[e.ScriptPath] = true
+		for _, n := range journal.FileNames(e) {
+			knownNames[n] = true
+			knownScripts[filepath.Join(sessionsDir, n+".sh")] = true
+		}
+
+		var missing []int
+		for _, g := range journal.History(e) {
+			if !exists(g.SnapshotPath) {
+				missing = append(missing, g.ID)
+			}
+		}
+		if len(missing) > 0 {
+			findings = append(findings, finding{
+				msg: fmt.Sprintf("%s: snapshot missing for generation(s) %s", name, joinInts(missing)),
+				fix: func() error {
+					e := journal.Find(data, name)
+					if e == nil {
+						return nil
+					}
+					var kept []journal.Generation
+					for _, g := range journal.History(*e) {
+						if exists(g.SnapshotPath) {
+							kept = append(kept, g)
+						}
+					}
+					if len(kept) == 0 {
+						data, _ = journal.Remove(data, name)
+						return saveJournal()
+					}
+					latest := kept[len(kept)-1]
+					e.Generations = kept
+					e.SnapshotPath, e.Windows, e.Panes, e.CapturedAt = latest.SnapshotPath, latest.Windows, latest.Panes, latest.CapturedAt
+					return saveJournal()
+				},
+			})
+		}
+
+		if !exists(e.ScriptPath) {
+			findings = append(findings, finding{
+				msg: fmt.Sprintf("%s: script %s does not exist", name, e.ScriptPath),
+				fix: func() error {
+					e := journal.Find(data, name)
+					if e == nil {
+						return nil
+					}
+					if e.SnapshotPath != "" && exists(e.SnapshotPath) {
+						doc, err := snapshot.ReadDocument(e.SnapshotPath)
+						if err == nil {
+							return writeScript(e.ScriptPath, doc.Session, settings.OnConflict)
+						}
+					}
+					data, _ = journal.Remove(data, name)
+					return saveJournal()
+				},
+			})
+		}
+
+		if e.SnapshotPath != "" && exists(e.SnapshotPath) {
+			if doc, err := snapshot.ReadDocument(e.SnapshotPath); err == nil {
+				for _, p := range missingPaths(doc.Session) {
+					findings = append(findings, finding{msg: fmt.Sprintf("%s: pane directory %s no longer exists", name, p)})
+				}
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(sessionsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// recoverable holds the names whose snapshots 'tforge adopt --all' can
+	// bring back together with their script. ReadDir sorts "name" before
+	// "name.sh", so directories are seen first.
+	recoverable := map[string]bool{}
+	for _, de := range entries {
+		path := filepath.Join(sessionsDir, de.Name())
+		name := strings.TrimSuffix(de.Name(), ".sh")
+		switch {
+		case !de.IsDir() && !strings.HasSuffix(de.Name(), ".sh"):
+			continue
+		case !de.IsDir() && (knownScripts[path] || recoverable[name]):
+			continue
+		case de.IsDir() && knownNames[name]:
+			continue
+		}
+		msg := fmt.Sprintf("%s is not referenced by the journal", path)
+		if de.IsDir() {
+			// Snapshots cannot be recreated, so never delete them here.
+			snapshots, _ := filepath.Glob(filepath.Join(path, "generations", "*", "snapshot.json"))
+			if len(snapshots) > 0 {
+				recoverable[name] = true
+				findings = append(findings, finding{
+					msg: fmt.Sprintf("%s holds %d snapshot(s) not referenced by the journal (recover them with 'tforge adopt --all')", path, len(snapshots)),
+				})
+				continue
+			}
+		} else {
+			msg += fmt.Sprintf(" (keep it with 'tforge adopt %s' before --fix)", path)
+		}
+		findings = append(findings, finding{
+			msg: msg,
+			fix: func() error { return os.RemoveAll(path) },
+		})
+	}
+
+	content, err := os.ReadFile(tmuxConf)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, b := range config.Bindings(string(content)) {
+		msg := fmt.Sprintf("%s: keybinding prefix + %s runs missing script %s", tmuxConf, b.Key, b.ScriptPath)
+		switch {
+		case !exists(b.ScriptPath):
+		case !knownNames[b.Name]:
+			msg = fmt.Sprintf("%s: keybinding prefix + %s belongs to %s, which is not in the journal", tmuxConf, b.Key, b.Name)
+		default:
+			continue
+		}
+		findings = append(findings, finding{
+			msg:  msg,
+			conf: true,
+			fix: func() error {
+				// An earlier fix may have regenerated the script.
+				if exists(b.ScriptPath) && journal.FindFiles(data, b.Name) != nil {
+					return nil
+				}
+				_, err := config.RemoveFile(tmuxConf, b.Name, b.ScriptPath)
+				return err
+			},
+		})
+	}
+	return findings, nil
+}
+
+// missingPaths returns the distinct pane directories of s that do not exist.
+func missingPaths(s snapshot.Session) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, w := range s.Windows {
+		for _, p := range w.Panes {
+			if p.Path == "" || seen[p.Path] {
+				continue
+			}
+			seen[p.Path] = true
+			if !exists(p.Path) {
+				out = append(out, p.Path)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = fmt.Sprint(n)
+	}
+	return strings.Join(s, ", ")
+}
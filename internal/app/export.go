@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"tforge/internal/cli"
+	"tforge/internal/export"
+	"tforge/internal/fsutil"
+	"tforge/internal/generate"
+	"tforge/internal/journal"
+	"tforge/internal/names"
+	"tforge/internal/snapshot"
+)
+
+func runExport(_ context.Context, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(out)
+	format := fs.String("format", "", "output format: tmuxp, tmuxinator or zellij")
+	name := fs.String("name", "", "saved layout to export (else fuzzy select)")
+	at := fs.String("at", "", "generation number or time to export (default: latest)")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" && fs.NArg() > 0 {
+		*name = fs.Arg(0)
+	}
+
+	var render func(snapshot.Session) ([]byte, error)
+	switch *format {
+	case "tmuxp":
+		render = export.Tmuxp
+	case "tmuxinator":
+		render = export.Tmuxinator
+	case "zellij":
+		render = export.Zellij
+	case "":
+		return errors.New("missing --format (tmuxp, tmuxinator or zellij)")
+	default:
+		return fmt.Errorf("unknown --format %q (supported: tmuxp, tmuxinator, zellij)", *format)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	jPath := journal.Path(home)
+	data, err := journal.Load(jPath)
+	if err != nil {
+		return err
+	}
+	if len(data.Entries) == 0 {
+		return errors.New("no saved sessions found; run 'tforge capture' first")
+	}
+	if *name == "" {
+		sel, ok, err := selectEntry(cli.NewPrompter(in, out), out, data, "Select a saved session to export")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("export cancelled")
+		}
+		*name = sel
+	}
+	if err := names.Validate(*name); err != nil {
+		return fmt.Errorf("invalid --name: %w", err)
+	}
+	entry := journal.Find(data, *name)
+	if entry == nil {
+		return fmt.Errorf("saved layout %q is not in journal %s", *name, jPath)
+	}
+
+	s, err := loadSession(*entry, *at)
+	if err != nil {
+		return err
+	}
+	b, err := render(s)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err := out.Write(b)
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(*output, b, 0o644); err != nil {
+		return err
+	}
+	cli.Info(out, "Wrote %s config: %s", *format, *output)
+	return nil
+}
+
+// loadSession returns the saved session of e, or of its generation at when
+// given. Entries from before snapshots existed are read back from their
+// script.
+func loadSession(e journal.Entry, at string) (snapshot.Session, error) {
+	path := e.SnapshotPath
+	if at != "" {
+		gen, err := journal.FindGeneration(e, at)
+		if err != nil {
+			return snapshot.Session{}, err
+		}
+		path = gen.SnapshotPath
+	}
+	if path != "" {
+		doc, err := snapshot.ReadDocument(path)
+		if err != nil {
+			return snapshot.Session{}, err
+		}
+		return doc.Session, nil
+	}
+	b, err := os.ReadFile(e.ScriptPath)
+	if err != nil {
+		return snapshot.Session{}, err
+	}
+	return generate.Parse(string(b))
+}
@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tforge/internal/cli"
+	"tforge/internal/config"
+	"tforge/internal/importer"
+	"tforge/internal/names"
+	"tforge/internal/snapshot"
+)
+
+func runImport(_ context.Context, args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("missing format; usage: tforge import resurrect|tmuxp|tmuxinator [flags] [file]")
+	}
+	format := args[0]
+	fs := flag.NewFlagSet("import "+format, flag.ContinueOnError)
+	fs.SetOutput(out)
+	session := fs.String("session", "", "session to import from the file (else fuzzy select)")
+	name := fs.String("name", "", "save the session under this name (default: session name)")
+	all := fs.Bool("all", false, "import every session in the file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *all && (*session != "" || *name != "") {
+		return errors.New("--all cannot be combined with --session or --name")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	var sessions []snapshot.Session
+	var current, path string
+	switch format {
+	case "resurrect":
+		path = fs.Arg(0)
+		if path == "" {
+			if path, err = latestResurrectSave(home); err != nil {
+				return err
+			}
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		res, err := importer.ParseResurrect(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		sessions, current = res.Sessions, res.Current
+	case "tmuxp", "tmuxinator":
+		if fs.NArg() == 0 {
+			return fmt.Errorf("missing %s project file or name", format)
+		}
+		if path, err = findProject(home, format, fs.Arg(0)); err != nil {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var s snapshot.Session
+		if format == "tmuxinator" {
+			s, err = importer.ParseTmuxinator(b, home)
+		} else {
+			s, err = importer.ParseTmuxp(b, filepath.Dir(path), home)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		sessions = []snapshot.Session{s}
+	default:
+		return fmt.Errorf("unknown import format %q (supported: resurrect, tmuxp, tmuxinator)", format)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	cli.Info(out, "Importing %s", path)
+
+	if !*all {
+		s, err := pickSession(cli.NewPrompter(in, out), out, sessions, *session, current)
+		if err != nil {
+			return err
+		}
+		sessions = []snapshot.Session{s}
+	}
+
+	settings, err := config.LoadSettings(config.SettingsPath(home))
+	if err != nil {
+		cli.Warn(out, "using default settings: %v", err)
+	}
+	for _, s := range sessions {
+		saveName := *name
+		if saveName == "" {
+			saveName = names.Slug(s.Name)
+		} else if err := names.Validate(saveName); err != nil {
+			return fmt.Errorf("invalid --name: %w (try %q)", err, names.Slug(saveName))
+		}
+		doc, gen, scriptPath, err := saveLayout(home, saveName, s, info.ModTime().UTC(), settings.OnConflict, out)
+		if err != nil {
+			return err
+		}
+		if err := updateJournal(home, saveName, doc, scriptPath, gen, settings.Retention, out); err != nil {
+			return err
+		}
+		cli.Info(out, "Imported session %s as %s.", s.Name, saveName)
+	}
+	return nil
+}
+
+// pickSession chooses the session to import: the one named by want, the only
+// one in the file, or one picked by the user with current listed first.
+func pickSession(prompt *cli.Prompter, out io.Writer, sessions []snapshot.Session, want, current string) (snapshot.Session, error) {
+	if want == "" && len(sessions) == 1 {
+		return sessions[0], nil
+	}
+	if want == "" {
+		options := make([]cli.Option, 0, len(sessions))
+		for _, s := range sessions {
+			panes := 0
+			for _, w := range s.Windows {
+				panes += len(w.Panes)
+			}
+			opt := cli.Option{ID: s.Name, Label: s.Name, Details: fmt.Sprintf("windows=%d panes=%d", len(s.Windows), panes)}
+			if s.Name == current {
+				options = append([]cli.Option{opt}, options...)
+				continue
+			}
+			options = append(options, opt)
+		}
+		sel, ok, err := cli.SelectFuzzy(prompt, out, "Select a session to import", options)
+		if err != nil {
+			return snapshot.Session{}, err
+		}
+		if !ok {
+			return snapshot.Session{}, errors.New("import cancelled")
+		}
+		want = sel
+	}
+	var available []string
+	for _, s := range sessions {
+		if s.Name == want {
+			return s, nil
+		}
+		available = append(available, s.Name)
+	}
+	return snapshot.Session{}, fmt.Errorf("session %q is not in the file (available: %s)", want, strings.Join(available, ", "))
+}
+
+// latestResurrectSave finds the newest tmux-resurrect save, preferring the
+// "last" link that tmux-resurrect maintains.
+func latestResurrectSave(home string) (string, error) {
+	dirs := []string{filepath.Join(home, ".tmux", "resurrect")}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		dirs = append([]string{filepath.Join(xdg, "tmux", "resurrect")}, dirs...)
+	} else {
+		dirs = append([]string{filepath.Join(home, ".local", "share", "tmux", "resurrect")}, dirs...)
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "last")); err == nil {
+			return filepath.Join(dir, "last"), nil
+		}
+		saves, _ := filepath.Glob(filepath.Join(dir, "tmux_resurrect_*.txt"))
+		if len(saves) > 0 {
+			// Names embed a sortable timestamp.
+			sort.Strings(saves)
+			return saves[len(saves)-1], nil
+		}
+	}
+	return "", fmt.Errorf("no tmux-resurrect saves found in %s", strings.Join(dirs, " or "))
+}
+
+// findProject resolves arg to a tmuxp or tmuxinator project file: either a
+// path, or a project name looked up where the tool keeps its configs.
+func findProject(home, format, arg string) (string, error) {
+	if _, err := os.Stat(arg); err == nil || strings.ContainsRune(arg, os.PathSeparator) {
+		return arg, nil
+	}
+	config := filepath.Join(home, ".config")
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		config = xdg
+	}
+	dirs := []string{filepath.Join(config, "tmuxp"), filepath.Join(home, ".tmuxp")}
+	if format == "tmuxinator" {
+		dirs = []string{filepath.Join(config, "tmuxinator"), filepath.Join(home, ".tmuxinator")}
+	}
+	for _, dir := range dirs {
+		for _, ext := range []string{".yml", ".yaml"} {
+			path := filepath.Join(dir, arg+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no %s project %q in %s", format, arg, strings.Join(dirs, " or "))
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"tforge/internal/cli"
+	"tforge/internal/journal"
+	"tforge/internal/tmux"
+)
+
+type listItem struct {
+	Name         string    `json:"name"`
+	Session      string    `json:"session"`
+	Windows      int       `json:"windows"`
+	Panes        int       `json:"panes"`
+	CapturedAt   time.Time `json:"captured_at"`
+	Generations  int       `json:"generations"`
+	ScriptPath   string    `json:"script_path"`
+	ScriptExists bool      `json:"script_exists"`
+	Running      bool      `json:"running"`
+}
+
+func runList(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(out)
+	asJSON := fs.Bool("json", false, "print machine-readable JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	data, err := journal.Load(journal.Path(home))
+	if err != nil {
+		return err
+	}
+
+	running := map[string]bool{}
+	sessions, err := tmux.NewService(tmux.NewCommandRunner()).ListSessions(ctx)
+	if err != nil {
+		// JSON consumers cannot see a warning, and "running": false would
+		// read as an answer rather than as unknown.
+		if *asJSON {
+			return fmt.Errorf("unable to query tmux sessions: %w", err)
+		}
+		cli.Warn(out, "unable to query tmux sessions: %v", err)
+	}
+	for _, s := range sessions {
+		running[s] = true
+	}
+
+	items := make([]listItem, 0, len(data.Entries))
+	for _, e := range data.Entries {
+		_, statErr := os.Stat(e.ScriptPath)
+		items = append(items, listItem{
+			Name:         e.Name,
+			Session:      e.Session,
+			Windows:      e.Windows,
+			Panes:        e.Panes,
+			CapturedAt:   e.CapturedAt,
+			Generations:  len(journal.History(e)),
+			ScriptPath:   e.ScriptPath,
+			ScriptExists: statErr == nil,
+			Running:      running[e.Session],
+		})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+	if len(items) == 0 {
+		cli.Info(out, "No saved sessions; run 'tforge capture' first.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSESSION\tWINDOWS\tPANES\tCAPTURED\tGENS\tSCRIPT\tRUNNING")
+	for _, it := range items {
+		script := it.ScriptPath
+		if !it.ScriptExists {
+			script += " (missing)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%d\t%s\t%s\n", it.Name, it.Session, it.Windows, it.Panes, it.CapturedAt.Local().Format("2006-01-02 15:04"), it.Generations, script, yesNo(it.Running))
+	}
+	return tw.Flush()
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"tforge/internal/cli"
+	"tforge/internal/generate"
+	"tforge/internal/journal"
+	"tforge/internal/names"
+)
+
+func runShow(_ context.Context, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	fs.SetOutput(out)
+	name := fs.String("name", "", "saved layout to show (else fuzzy select)")
+	at := fs.String("at", "", "generation number or time to show (default: latest)")
+	width := fs.Int("width", 80, "diagram width in columns")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" && fs.NArg() > 0 {
+		*name = fs.Arg(0)
+	}
+	if *width < 10 {
+		return fmt.Errorf("--width must be at least 10, got %d", *width)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	jPath := journal.Path(home)
+	data, err := journal.Load(jPath)
+	if err != nil {
+		return err
+	}
+	if len(data.Entries) == 0 {
+		return errors.New("no saved sessions found; run 'tforge capture' first")
+	}
+	if *name == "" {
+		sel, ok, err := selectEntry(cli.NewPrompter(in, out), out, data, "Select a saved session to show")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("show cancelled")
+		}
+		*name = sel
+	}
+	if err := names.Validate(*name); err != nil {
+		return fmt.Errorf("invalid --name: %w", err)
+	}
+	entry := journal.Find(data, *name)
+	if entry == nil {
+		return fmt.Errorf("saved layout %q is not in journal %s", *name, jPath)
+	}
+
+	s, err := loadSession(*entry, *at)
+	if err != nil {
+		return err
+	}
+	panes := 0
+	for _, w := range s.Windows {
+		panes += len(w.Panes)
+	}
+	captured := entry.CapturedAt
+	if *at != "" {
+		if gen, err := journal.FindGeneration(*entry, *at); err == nil {
+			captured = gen.CapturedAt
+		}
+	}
+	fmt.Fprintf(out, "%s (session %s, windows=%d, panes=%d, captured %s)\n\n", entry.Name, entry.Session, len(s.Windows), panes, captured.Local().Format(time.RFC3339))
+	for _, l := range generate.Preview(s, *width, home) {
+		fmt.Fprintln(out, l)
+	}
+	return nil
+}
@@ -13,8 +13,16 @@ type Option struct {
 	ID      string
 	Label   string
 	Details string
+	// Preview, if set, returns lines describing the option at most width
+	// columns wide. The selector shows them beside the list when the
+	// terminal has room.
+	Preview func(width int) []string
 }
 
+// The list keeps at most listWidth columns when a preview is shown; a preview
+// narrower than minPreviewWidth is not worth drawing.
+const listWidth, minPreviewWidth = 48, 30
+
 // SelectFuzzy tries an interactive arrow-key selector when a TTY is available.
 // If TTY interaction is unavailable, it falls back to a numbered prompt selector.
 func SelectFuzzy(p *Prompter, out io.Writer, title string, options []Option) (string, bool, error) {
@@ -37,6 +45,7 @@ func SelectFuzzy(p *Prompter, out io.Writer, title string, options []Option) (st
 	if err := setRawTTY(tty); err != nil {
 		return selectFallback(p, out, title, options)
 	}
+	rows, cols := ttySize(tty)
 
 	all := append([]Option{{ID: "", Label: "Exit", Details: "Cancel"}}, options...)
 	query := ""
@@ -54,7 +63,7 @@ func SelectFuzzy(p *Prompter, out io.Writer, title string, options []Option) (st
 			selected = 0
 		}
 
-		renderSelect(tty, title, query, filtered, selected)
+		renderSelect(tty, title, query, filtered, selected, rows, cols)
 
 		buf := make([]byte, 3)
 		n, rErr := tty.Read(buf)
@@ -118,7 +127,7 @@ func selectFallback(p *Prompter, out io.Writer, title string, options []Option)
 	}
 }
 
-func renderSelect(tty *os.File, title, query string, options []Option, selected int) {
+func renderSelect(tty *os.File, title, query string, options []Option, selected, rows, cols int) {
 	fmt.Fprint(tty, "\033[H\033[2J")
 	fmt.Fprintf(tty, "%s\n", title)
 	fmt.Fprint(tty, "Type to filter • ↑/↓ to move • Enter to select • q to cancel\n")
@@ -128,6 +137,7 @@ func renderSelect(tty *os.File, title, query string, options []Option, selected
 	if max > 12 {
 		max = 12
 	}
+	lines := make([]string, 0, max)
 	for i := 0; i < max; i++ {
 		prefix := "  "
 		if i == selected {
@@ -135,13 +145,46 @@ func renderSelect(tty *os.File, title, query string, options []Option, selected
 		}
 		opt := options[i]
 		if strings.TrimSpace(opt.Details) == "" {
-			fmt.Fprintf(tty, "%s%s\n", prefix, opt.Label)
+			lines = append(lines, prefix+opt.Label)
 		} else {
-			fmt.Fprintf(tty, "%s%s (%s)\n", prefix, opt.Label, opt.Details)
+			lines = append(lines, fmt.Sprintf("%s%s (%s)", prefix, opt.Label, opt.Details))
+		}
+	}
+
+	left := min(listWidth, cols/2)
+	width := cols - left - 3
+	preview := options[selected].Preview
+	if preview == nil || width < minPreviewWidth {
+		for _, l := range lines {
+			fmt.Fprintln(tty, l)
+		}
+		return
+	}
+	right := preview(width)
+	if height := rows - 5; height >= 0 && len(right) > height {
+		right = right[:height]
+	}
+	for i := 0; i < len(lines) || i < len(right); i++ {
+		l, r := "", ""
+		if i < len(lines) {
+			l = lines[i]
 		}
+		if i < len(right) {
+			r = fitWidth(right[i], width)
+		}
+		fmt.Fprintf(tty, "%s │ %s\n", fitWidth(l, left), r)
 	}
 }
 
+// fitWidth cuts or pads s to exactly width runes.
+func fitWidth(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
 func filterOptions(options []Option, query string) []Option {
 	if strings.TrimSpace(query) == "" {
 		return options
@@ -185,3 +228,17 @@ func restoreTTY(tty *os.File, state string) {
 	cmd.Stderr = io.Discard
 	_ = cmd.Run()
 }
+
+// ttySize returns the terminal's rows and columns, or zeros if unknown.
+func ttySize(tty *os.File) (rows, cols int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0
+	}
+	if _, err := fmt.Sscanf(string(out), "%d %d", &rows, &cols); err != nil {
+		return 0, 0
+	}
+	return rows, cols
+}
@@ -75,3 +75,20 @@ func (p *Prompter) AskYesNo(label string, def bool) (bool, error) {
 		}
 	}
 }
+
+// AskValid asks with a default until validate accepts the answer. After an
+// invalid answer, suggest(answer) is offered as the new default.
+func (p *Prompter) AskValid(label, def string, validate func(string) error, suggest func(string) string) (string, error) {
+	for {
+		v, err := p.AskDefault(label, def)
+		if err != nil {
+			return "", err
+		}
+		if err := validate(v); err != nil {
+			fmt.Fprintf(p.out, "%s %v\n", prefixWarn, err)
+			def = suggest(v)
+			continue
+		}
+		return v, nil
+	}
+}
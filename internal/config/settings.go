@@ -0,0 +1,56 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tforge/internal/onconflict"
+)
+
+// Settings holds user preferences read from ~/.tforge/config.json. Missing
+// fields keep their defaults.
+type Settings struct {
+	Retention Retention `json:"retention"`
+	// OnConflict is what restore does when the session is already running.
+	OnConflict onconflict.Strategy `json:"on_conflict"`
+}
+
+type Retention struct {
+	// KeepGenerations is how many captures to keep per saved layout; 0 keeps all.
+	KeepGenerations int `json:"keep_generations"`
+	// MaxAgeDays drops captures older than this many days; 0 disables it.
+	MaxAgeDays int `json:"max_age_days"`
+}
+
+func (r Retention) MaxAge() time.Duration {
+	return time.Duration(r.MaxAgeDays) * 24 * time.Hour
+}
+
+func DefaultSettings() Settings {
+	return Settings{Retention: Retention{KeepGenerations: 10}, OnConflict: onconflict.Attach}
+}
+
+func SettingsPath(home string) string {
+	return filepath.Join(home, ".tforge", "config.json")
+}
+
+func LoadSettings(path string) (Settings, error) {
+	s := DefaultSettings()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, err
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return DefaultSettings(), fmt.Errorf("parse %s: %w", path, err)
+	}
+	if s.Retention.KeepGenerations < 0 || s.Retention.MaxAgeDays < 0 {
+		return DefaultSettings(), fmt.Errorf("%s: retention values must not be negative", path)
+	}
+	return s, nil
+}
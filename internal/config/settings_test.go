@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tforge/internal/onconflict"
+)
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Retention.KeepGenerations != DefaultSettings().Retention.KeepGenerations {
+		t.Fatalf("expected defaults for a missing file, got %+v", s)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"retention": {"max_age_days": 30}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Retention.MaxAgeDays != 30 || s.Retention.KeepGenerations != 10 {
+		t.Fatalf("expected partial override of defaults, got %+v", s)
+	}
+}
+
+func TestLoadSettingsConflictStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"on_conflict": "merge"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.OnConflict != onconflict.Merge || s.Retention.KeepGenerations != 10 {
+		t.Fatalf("unexpected settings %+v", s)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"on_conflict": "clobber"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadSettings(path)
+	if err == nil || !strings.Contains(err.Error(), "clobber") {
+		t.Fatalf("expected unknown strategy to be rejected, got %v", err)
+	}
+	if s.OnConflict != onconflict.Attach {
+		t.Fatalf("expected defaults after an error, got %+v", s)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"tforge/internal/fsutil"
+	"tforge/internal/names"
+	"tforge/internal/shell"
 )
 
 func CommonKeyWarning(key string) string {
@@ -14,7 +18,48 @@ func CommonKeyWarning(key string) string {
 	return ""
 }
 
+// keyNames are the named tmux keys ValidateKey accepts besides single
+// characters.
+var keyNames = map[string]bool{
+	"Up": true, "Down": true, "Left": true, "Right": true,
+	"Home": true, "End": true, "PageUp": true, "PageDown": true, "PPage": true, "NPage": true,
+	"Tab": true, "BTab": true, "Space": true, "Enter": true, "Escape": true, "BSpace": true,
+	"IC": true, "DC": true,
+	"F1": true, "F2": true, "F3": true, "F4": true, "F5": true, "F6": true,
+	"F7": true, "F8": true, "F9": true, "F10": true, "F11": true, "F12": true,
+}
+
+// keyPunctuation are the punctuation keys that can be written into a
+// bind-key line without quoting.
+const keyPunctuation = "!%&()*+,-./:<=>?@[]^_|"
+
+// ValidateKey reports whether key is a tmux key that can be written into a
+// bind-key line as is: a letter, digit or punctuation character, or a named
+// key such as F1 or Up, optionally after C-, M- or S- modifiers.
+func ValidateKey(key string) error {
+	base := key
+	for len(base) > 2 && (strings.HasPrefix(base, "C-") || strings.HasPrefix(base, "M-") || strings.HasPrefix(base, "S-")) {
+		base = base[2:]
+	}
+	switch {
+	case keyNames[base]:
+		return nil
+	case len(base) == 1:
+		c := base[0]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(keyPunctuation, c) >= 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid key %q: use a letter, digit, punctuation character or a tmux key name such as F1, optionally with C-, M- or S-", key)
+}
+
 func UpdateFile(path, sessionName, key, scriptPath string) (updated bool, changed bool, err error) {
+	if err := names.Validate(sessionName); err != nil {
+		return false, false, err
+	}
+	if err := ValidateKey(key); err != nil {
+		return false, false, err
+	}
 	content, err := os.ReadFile(path)
 	if err != nil && !os.IsNotExist(err) {
 		return false, false, err
@@ -23,7 +68,7 @@ func UpdateFile(path, sessionName, key, scriptPath string) (updated bool, change
 	if newContent == string(content) {
 		return false, false, nil
 	}
-	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+	if err := fsutil.WriteFileAtomic(path, []byte(newContent), 0o644); err != nil {
 		return false, false, err
 	}
 	return true, true, nil
@@ -32,12 +77,63 @@ func UpdateFile(path, sessionName, key, scriptPath string) (updated bool, change
 func UpdateContent(content, sessionName, key, scriptPath string) string {
 	begin := fmt.Sprintf("# tforge begin: %s", sessionName)
 	end := fmt.Sprintf("# tforge end: %s", sessionName)
+	bindLine := fmt.Sprintf("bind-key %s run-shell %s", key, runShell(scriptPath))
+
+	kept := stripBlock(content, sessionName, scriptPath)
+	block := []string{
+		begin,
+		fmt.Sprintf("unbind-key %s", key),
+		bindLine,
+		end,
+	}
+	if len(kept) > 0 {
+		kept = append(kept, "")
+	}
+	kept = append(kept, block...)
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// RemoveFile drops the keybinding block for sessionName, and any other binding
+// that runs scriptPath, from the tmux config at path. Blocks written before
+// names were validated may carry any name that fits on their marker line.
+func RemoveFile(path, sessionName, scriptPath string) (changed bool, err error) {
+	if sessionName == "" || strings.ContainsAny(sessionName, "\r\n") {
+		return false, fmt.Errorf("invalid keybinding name %q", sessionName)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	newContent := RemoveContent(string(content), sessionName, scriptPath)
+	if newContent == string(content) {
+		return false, nil
+	}
+	if err := fsutil.WriteFileAtomic(path, []byte(newContent), 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func RemoveContent(content, sessionName, scriptPath string) string {
+	kept := stripBlock(content, sessionName, scriptPath)
+	if len(kept) == 0 {
+		return ""
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// stripBlock returns the lines of content without the tforge block for
+// sessionName, stray bindings of scriptPath and trailing blank lines.
+func stripBlock(content, sessionName, scriptPath string) []string {
+	begin := fmt.Sprintf("# tforge begin: %s", sessionName)
+	end := fmt.Sprintf("# tforge end: %s", sessionName)
 
 	lines := strings.Split(content, "\n")
 	var kept []string
 	inBlock := false
-	bindLine := fmt.Sprintf("bind-key %s run-shell \"/usr/bin/env bash %s\"", key, scriptPath)
-
 	for _, line := range lines {
 		trim := strings.TrimSpace(line)
 		switch {
@@ -49,7 +145,7 @@ func UpdateContent(content, sessionName, key, scriptPath string) string {
 			continue
 		case inBlock:
 			continue
-		case strings.Contains(trim, fmt.Sprintf("run-shell \"/usr/bin/env bash %s\"", scriptPath)):
+		case bindsScript(trim, scriptPath):
 			continue
 		default:
 			kept = append(kept, line)
@@ -59,16 +155,60 @@ func UpdateContent(content, sessionName, key, scriptPath string) string {
 	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
 		kept = kept[:len(kept)-1]
 	}
+	return kept
+}
 
-	block := []string{
-		begin,
-		fmt.Sprintf("unbind-key %s", key),
-		bindLine,
-		end,
+// Binding is a keybinding block written by UpdateContent.
+type Binding struct {
+	Name       string
+	Key        string
+	ScriptPath string
+}
+
+// Bindings lists the tforge blocks in content, in file order.
+func Bindings(content string) []Binding {
+	const beginPrefix = "# tforge begin: "
+	var out []Binding
+	var cur *Binding
+	for _, line := range strings.Split(content, "\n") {
+		trim := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trim, beginPrefix):
+			out = append(out, Binding{Name: strings.TrimPrefix(trim, beginPrefix)})
+			cur = &out[len(out)-1]
+		case cur != nil && trim == "# tforge end: "+cur.Name:
+			cur = nil
+		case cur != nil && strings.HasPrefix(trim, "bind-key "):
+			cur.Key, cur.ScriptPath, _ = parseBindLine(trim)
+		}
 	}
-	if len(kept) > 0 {
-		kept = append(kept, "")
+	return out
+}
+
+// runShell returns the run-shell argument that runs scriptPath: the path is
+// quoted for the shell, and the command for a tmux double-quoted string, in
+// which '"', '\' and '$' are special.
+func runShell(scriptPath string) string {
+	cmd := "/usr/bin/env bash " + shell.Quote(scriptPath)
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(cmd) + `"`
+}
+
+// parseBindLine reads the key and script path back from a line written by
+// UpdateContent. Lines from earlier releases left the path unquoted.
+func parseBindLine(line string) (key, scriptPath string, ok bool) {
+	words, err := shell.Split(line)
+	if err != nil || len(words) != 4 || words[0] != "bind-key" || words[2] != "run-shell" {
+		return "", "", false
 	}
-	kept = append(kept, block...)
-	return strings.Join(kept, "\n") + "\n"
+	cmd, err := shell.Split(words[3])
+	if err != nil || len(cmd) < 3 || cmd[0] != "/usr/bin/env" || cmd[1] != "bash" {
+		return words[1], "", false
+	}
+	return words[1], strings.Join(cmd[2:], " "), true
+}
+
+// bindsScript reports whether line is a bind-key line that runs scriptPath.
+func bindsScript(line, scriptPath string) bool {
+	_, path, ok := parseBindLine(line)
+	return ok && path == scriptPath
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -20,3 +22,84 @@ func TestUpdateContentAddsSingleBlock(t *testing.T) {
 		t.Fatal("expected idempotent block update")
 	}
 }
+
+func TestRemoveContent(t *testing.T) {
+	initial := "set -g mouse on\n"
+	withBlock := UpdateContent(initial, "hive", "g", "/home/me/.tforge/sessions/hive.sh")
+	withBlock = UpdateContent(withBlock, "ops", "o", "/home/me/.tforge/sessions/ops.sh")
+
+	out := RemoveContent(withBlock, "hive", "/home/me/.tforge/sessions/hive.sh")
+	if strings.Contains(out, "hive") {
+		t.Fatalf("expected hive block to be removed:\n%s", out)
+	}
+	if !strings.Contains(out, "# tforge begin: ops") || !strings.HasPrefix(out, initial) {
+		t.Fatalf("expected other content to be kept:\n%s", out)
+	}
+	if again := RemoveContent(out, "hive", "/home/me/.tforge/sessions/hive.sh"); again != out {
+		t.Fatal("expected removal to be idempotent")
+	}
+}
+
+func TestUpdateFileRejectsInvalidNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tmux.conf")
+	if _, _, err := UpdateFile(path, "x\n# tforge end: y", "g", "/tmp/x.sh"); err == nil {
+		t.Fatal("expected invalid name to be rejected")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expected tmux config to be left untouched")
+	}
+}
+
+func TestUpdateFileRejectsInvalidKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tmux.conf")
+	for _, key := range []string{"", "x; run-shell 'rm -rf ~'", "ab", "\"", "#", "C-"} {
+		if _, _, err := UpdateFile(path, "hive", key, "/tmp/x.sh"); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+	for _, key := range []string{"g", "G", "%", "F5", "C-a", "M-Up", "C-M-x"} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("expected key %q to be accepted: %v", key, err)
+		}
+	}
+}
+
+func TestUpdateContentQuotesScriptPath(t *testing.T) {
+	script := `/home/Jo "JJ" O'Neil/$HOME/.tforge/sessions/hive.sh`
+	content := UpdateContent("", "hive", "g", script)
+	if !strings.Contains(content, `bind-key g run-shell "/usr/bin/env bash '/home/Jo \"JJ\" O'\\''Neil/\$HOME/.tforge/sessions/hive.sh'"`) {
+		t.Fatalf("unexpected bind line:\n%s", content)
+	}
+	if got := Bindings(content); len(got) != 1 || got[0].ScriptPath != script {
+		t.Fatalf("expected the path to read back unchanged, got %+v", got)
+	}
+	bindLine := strings.Split(content, "\n")[2]
+	if out := RemoveContent("set -g mouse on\n"+bindLine+"\n", "other", script); out != "set -g mouse on\n" {
+		t.Fatalf("expected the stray binding to be removed, got:\n%s", out)
+	}
+}
+
+func TestBindingsReadsUnquotedPaths(t *testing.T) {
+	content := "# tforge begin: hive\nunbind-key g\nbind-key g run-shell \"/usr/bin/env bash /home/me/.tforge/sessions/hive.sh\"\n# tforge end: hive\n"
+	if got := Bindings(content); len(got) != 1 || got[0].Key != "g" || got[0].ScriptPath != "/home/me/.tforge/sessions/hive.sh" {
+		t.Fatalf("unexpected bindings: %+v", got)
+	}
+}
+
+func TestBindings(t *testing.T) {
+	content := UpdateContent("set -g mouse on\n", "hive", "g", "/home/me/.tforge/sessions/hive.sh")
+	content = UpdateContent(content, "ops", "o", "/home/me/.tforge/sessions/ops.sh")
+	got := Bindings(content)
+	want := []Binding{
+		{Name: "hive", Key: "g", ScriptPath: "/home/me/.tforge/sessions/hive.sh"},
+		{Name: "ops", Key: "o", ScriptPath: "/home/me/.tforge/sessions/ops.sh"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected bindings: %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("binding %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
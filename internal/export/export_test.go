@@ -0,0 +1,113 @@
+package export
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"tforge/internal/layout"
+	"tforge/internal/snapshot"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// tmuxLayout adds the checksum tmux expects in front of a layout body.
+func tmuxLayout(body string) string {
+	return fmt.Sprintf("%04x,%s", layout.Checksum(body), body)
+}
+
+func testSession() snapshot.Session {
+	return snapshot.Session{
+		Name:         "hive",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: tmuxLayout("160x40,0,0{80x40,0,0,0,79x40,81,0,1}"), Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src", Command: "vim", Argv: []string{"vim", "main.go"}},
+				{Index: 1, Path: "/home/me/src", Command: "bash"},
+			}},
+			{Index: 1, Name: "servers", Layout: tmuxLayout("160x40,0,0[160x20,0,0,2,160x19,0,21{80x19,0,21,3,79x19,81,21,4}]"), ActivePane: 1, Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src/api", Command: "node", Argv: []string{"npm", "run", "dev"}},
+				{Index: 1, Path: "/var/log/my app", Command: "tail", Argv: []string{"tail", "-f", "it's.log"}},
+				{Index: 2, Path: "/home/me/src/api", Command: "zsh"},
+			}},
+		},
+	}
+}
+
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("%s mismatch (run go test -update to accept):\n got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestTmuxp(t *testing.T) {
+	out, err := Tmuxp(testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "hive.tmuxp.yaml", out)
+}
+
+func TestTmuxinator(t *testing.T) {
+	out, err := Tmuxinator(testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "hive.tmuxinator.yml", out)
+}
+
+func TestZellij(t *testing.T) {
+	s := snapshot.Session{
+		Name:         "hive",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: tmuxLayout("160x40,0,0{80x40,0,0,0,79x40,81,0[79x30,81,0,1,79x9,81,31,2]}"), ActivePane: 1, Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src", Command: "vim", Argv: []string{"vim", "main.go"}},
+				{Index: 1, Path: "/home/me/src", Command: "bash"},
+				{Index: 2, Path: "/home/me/my \"src\"", StartCommand: "npm run dev"},
+			}},
+			{Index: 1, Name: "logs", Layout: "tiled", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/var/log"},
+				{Index: 1, Path: "/var/log", Argv: []string{"tail", "-f", "syslog"}},
+			}},
+		},
+	}
+	out, err := Zellij(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "hive.zellij.kdl", out)
+}
+
+func TestZellijPercentagesAddUp(t *testing.T) {
+	c, err := layout.Parse(tmuxLayout("100x30,0,0{33x30,0,0,0,32x30,34,0,1,33x30,67,0,2}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for i := range c.Children {
+		n, err := strconv.Atoi(strings.TrimSuffix(percent(c, i), "%"))
+		if err != nil {
+			t.Fatalf("unexpected percentage %q", percent(c, i))
+		}
+		total += n
+	}
+	if total != 100 {
+		t.Fatalf("percentages add up to %d", total)
+	}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"fmt"
+
+	"tforge/internal/generate"
+	"tforge/internal/shell"
+	"tforge/internal/snapshot"
+)
+
+type tmuxinatorProject struct {
+	Name          string                        `yaml:"name"`
+	Root          string                        `yaml:"root,omitempty"`
+	StartupWindow string                        `yaml:"startup_window"`
+	StartupPane   int                           `yaml:"startup_pane"`
+	Windows       []map[string]tmuxinatorWindow `yaml:"windows"`
+}
+
+type tmuxinatorWindow struct {
+	Root   string `yaml:"root,omitempty"`
+	Layout string `yaml:"layout,omitempty"`
+	// Each pane is nil (a plain shell), a command, or a list of commands.
+	Panes []any `yaml:"panes"`
+}
+
+// Tmuxinator renders s as a tmuxinator project. tmuxinator has no per-pane
+// start directory, so panes outside their window's root begin with a cd.
+func Tmuxinator(s snapshot.Session) ([]byte, error) {
+	if len(s.Windows) == 0 || len(s.Windows[0].Panes) == 0 {
+		return nil, fmt.Errorf("session has no windows")
+	}
+	doc := tmuxinatorProject{Name: s.Name, Root: s.Windows[0].Panes[0].Path}
+	for _, w := range s.Windows {
+		if len(w.Panes) == 0 {
+			return nil, fmt.Errorf("window %q has no panes", w.Name)
+		}
+		tw := tmuxinatorWindow{Layout: w.Layout}
+		root := doc.Root
+		if p := w.Panes[0].Path; p != root {
+			tw.Root, root = p, p
+		}
+		for _, p := range w.Panes {
+			var cmds []string
+			if p.Path != "" && p.Path != root {
+				cmds = append(cmds, "cd "+shell.Quote(p.Path))
+			}
+			if cmd := generate.ReplayCommand(p); cmd != "" {
+				cmds = append(cmds, cmd)
+			}
+			switch len(cmds) {
+			case 0:
+				tw.Panes = append(tw.Panes, nil)
+			case 1:
+				tw.Panes = append(tw.Panes, cmds[0])
+			default:
+				tw.Panes = append(tw.Panes, cmds)
+			}
+		}
+		if w.Index == s.ActiveWindow {
+			doc.StartupWindow = w.Name
+			doc.StartupPane = w.ActivePane
+		}
+		doc.Windows = append(doc.Windows, map[string]tmuxinatorWindow{w.Name: tw})
+	}
+	return marshal(doc)
+}
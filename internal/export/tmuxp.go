@@ -0,0 +1,65 @@
+// Package export renders snapshot sessions in the configuration formats of
+// other tmux session managers.
+package export
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"tforge/internal/generate"
+	"tforge/internal/snapshot"
+)
+
+type tmuxpSession struct {
+	SessionName string        `yaml:"session_name"`
+	Windows     []tmuxpWindow `yaml:"windows"`
+}
+
+type tmuxpWindow struct {
+	WindowName string      `yaml:"window_name"`
+	Layout     string      `yaml:"layout,omitempty"`
+	Focus      bool        `yaml:"focus,omitempty"`
+	Panes      []tmuxpPane `yaml:"panes"`
+}
+
+type tmuxpPane struct {
+	StartDirectory string   `yaml:"start_directory,omitempty"`
+	ShellCommand   []string `yaml:"shell_command,omitempty"`
+	Focus          bool     `yaml:"focus,omitempty"`
+}
+
+// Tmuxp renders s as a tmuxp workspace file.
+func Tmuxp(s snapshot.Session) ([]byte, error) {
+	if len(s.Windows) == 0 {
+		return nil, fmt.Errorf("session has no windows")
+	}
+	doc := tmuxpSession{SessionName: s.Name}
+	for _, w := range s.Windows {
+		tw := tmuxpWindow{WindowName: w.Name, Layout: w.Layout, Focus: w.Index == s.ActiveWindow}
+		for _, p := range w.Panes {
+			tp := tmuxpPane{StartDirectory: p.Path, Focus: p.Index == w.ActivePane}
+			if cmd := generate.ReplayCommand(p); cmd != "" {
+				tp.ShellCommand = []string{cmd}
+			}
+			tw.Panes = append(tw.Panes, tp)
+		}
+		doc.Windows = append(doc.Windows, tw)
+	}
+	return marshal(doc)
+}
+
+// marshal encodes v with the two-space indentation both tools document.
+func marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tforge/internal/generate"
+	"tforge/internal/layout"
+	"tforge/internal/snapshot"
+)
+
+// zellijTabTemplate wraps every tab in Zellij's tab and status bars, which a
+// layout that defines its own tabs otherwise goes without.
+const zellijTabTemplate = `    default_tab_template {
+        pane size=1 borderless=true {
+            plugin location="zellij:tab-bar"
+        }
+        children
+        pane size=2 borderless=true {
+            plugin location="zellij:status-bar"
+        }
+    }
+`
+
+// Zellij renders s as a Zellij KDL layout: one tab per window, with the tmux
+// window layout translated into nested splits sized in percent. Windows whose
+// layout cannot be read (e.g. a named layout such as "tiled") get their panes
+// stacked evenly.
+func Zellij(s snapshot.Session) ([]byte, error) {
+	if len(s.Windows) == 0 {
+		return nil, fmt.Errorf("session has no windows")
+	}
+	var b strings.Builder
+	b.WriteString("layout {\n")
+	b.WriteString(zellijTabTemplate)
+	for _, w := range s.Windows {
+		if len(w.Panes) == 0 {
+			return nil, fmt.Errorf("window %q has no panes", w.Name)
+		}
+		fmt.Fprintf(&b, "    tab name=%s", kdlString(w.Name))
+		if w.Index == s.ActiveWindow {
+			b.WriteString(" focus=true")
+		}
+		b.WriteString(" {\n")
+		root, err := layout.Parse(w.Layout)
+		if err != nil || len(root.Panes()) != len(w.Panes) {
+			root = layout.Stacked(len(w.Panes))
+		}
+		panes := w.Panes
+		writeZellijCell(&b, root, w, &panes, "", 2)
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+	return []byte(b.String()), nil
+}
+
+func writeZellijCell(b *strings.Builder, c *layout.Cell, w snapshot.Window, panes *[]snapshot.Pane, size string, depth int) {
+	indent := strings.Repeat("    ", depth)
+	attrs := ""
+	if size != "" {
+		attrs += " size=" + kdlString(size)
+	}
+	if c.Kind == layout.Pane {
+		p := (*panes)[0]
+		*panes = (*panes)[1:]
+		if p.Path != "" {
+			attrs += " cwd=" + kdlString(p.Path)
+		}
+		argv := zellijArgv(p)
+		if len(argv) > 0 {
+			attrs += " command=" + kdlString(argv[0])
+		}
+		if p.Index == w.ActivePane {
+			attrs += " focus=true"
+		}
+		if len(argv) < 2 {
+			fmt.Fprintf(b, "%spane%s\n", indent, attrs)
+			return
+		}
+		fmt.Fprintf(b, "%spane%s {\n%s    args", indent, attrs, indent)
+		for _, a := range argv[1:] {
+			b.WriteString(" " + kdlString(a))
+		}
+		fmt.Fprintf(b, "\n%s}\n", indent)
+		return
+	}
+	// A tmux {} split places children side by side, separated by a vertical
+	// line, which is what Zellij calls a vertical split.
+	direction := "horizontal"
+	if c.Kind == layout.LeftRight {
+		direction = "vertical"
+	}
+	fmt.Fprintf(b, "%spane%s split_direction=%s {\n", indent, attrs, kdlString(direction))
+	for i, child := range c.Children {
+		writeZellijCell(b, child, w, panes, percent(c, i), depth+1)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// zellijArgv returns the command a pane should run. Zellij starts commands
+// directly, so command lines that were typed into a shell go through sh -c
+// and hand over to the user's shell afterwards, as they would in tmux.
+func zellijArgv(p snapshot.Pane) []string {
+	if len(p.Argv) > 0 && !generate.IsShell(p.Argv[0]) {
+		return p.Argv
+	}
+	if cmd := generate.ReplayCommand(p); cmd != "" {
+		return []string{"sh", "-c", cmd + "; exec \"${SHELL:-/bin/sh}\""}
+	}
+	return nil
+}
+
+// kdlString quotes s as a KDL string.
+func kdlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u{%x}`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// percent returns the share of child i along the split of c, rounded so that
+// the children always add up to 100%.
+func percent(c *layout.Cell, i int) string {
+	size := func(child *layout.Cell) int {
+		if c.Kind == layout.LeftRight {
+			return child.Width
+		}
+		return child.Height
+	}
+	total, before := 0, 0
+	for j, child := range c.Children {
+		total += size(child)
+		if j < i {
+			before += size(child)
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	lo := before * 100 / total
+	hi := (before + size(c.Children[i])) * 100 / total
+	if i == len(c.Children)-1 {
+		hi = 100
+	}
+	return strconv.Itoa(hi-lo) + "%"
+}
@@ -0,0 +1,77 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockedError reports that another process kept a lock for longer than the
+// caller was willing to wait.
+type LockedError struct {
+	Path string
+	PID  int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("%s is locked by another tforge process (pid %d); try again once it finishes", e.Path, e.PID)
+	}
+	return fmt.Sprintf("%s is locked by another tforge process; try again once it finishes", e.Path)
+}
+
+// lockPoll is how often Lock retries while another process holds the lock.
+const lockPoll = 50 * time.Millisecond
+
+// Lock takes an exclusive advisory lock on path, creating it if needed, and
+// waits up to timeout for another holder to release it. The lock file records
+// the holder's PID so that a timed-out caller can say who is in the way. The
+// returned function releases the lock.
+func Lock(path string, timeout time.Duration) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, &LockedError{Path: path, PID: lockHolder(path)}
+		}
+		time.Sleep(lockPoll)
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return func() error {
+		_ = f.Truncate(0)
+		if err := unlock(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}, nil
+}
+
+func lockHolder(path string) int {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	return pid
+}
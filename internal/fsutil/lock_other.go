@@ -0,0 +1,10 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+// Without flock the lock is advisory only in name: every caller succeeds.
+func tryLock(*os.File) (bool, error) { return true, nil }
+
+func unlock(*os.File) error { return nil }
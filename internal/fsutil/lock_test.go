@@ -0,0 +1,54 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockExcludesSecondHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tforge", "lock")
+	release, err := Lock(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Lock(path, 120*time.Millisecond)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected LockedError, got %v", err)
+	}
+	if locked.PID != os.Getpid() {
+		t.Fatalf("expected holder pid %d, got %d", os.Getpid(), locked.PID)
+	}
+
+	if err := release(); err != nil {
+		t.Fatal(err)
+	}
+	release, err = Lock(path, 120*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected lock to be free after release: %v", err)
+	}
+	_ = release()
+}
+
+func TestLockWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	release, err := Lock(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = release()
+	}()
+	second, err := Lock(path, 2*time.Second)
+	if err != nil {
+		t.Fatalf("expected to acquire lock once released: %v", err)
+	}
+	_ = second()
+}
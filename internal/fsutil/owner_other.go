@@ -0,0 +1,9 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+func chownLike(*os.File, os.FileInfo) error {
+	return nil
+}
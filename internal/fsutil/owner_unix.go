@@ -0,0 +1,26 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike gives f the owner and group of existing when they differ from
+// what f was created with.
+func chownLike(f *os.File, existing os.FileInfo) error {
+	want, ok := existing.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	have, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || (have.Uid == want.Uid && have.Gid == want.Gid) {
+		return nil
+	}
+	return f.Chown(int(want.Uid), int(want.Gid))
+}
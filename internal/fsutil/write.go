@@ -1,13 +1,93 @@
 package fsutil
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"syscall"
+)
+
+// Hooks for simulating failures in tests.
+var (
+	writeFile = func(f *os.File, b []byte) (int, error) { return f.Write(b) }
+	syncFile  = func(f *os.File) error { return f.Sync() }
+	rename    = os.Rename
+	chown     = chownLike
 )
 
 func WriteExecutable(path string, content []byte) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	return WriteFileAtomic(path, content, 0o755)
+}
+
+// WriteFileAtomic replaces path with content so that readers observe either
+// the old or the new file, never a truncated one. The data is written to a
+// temporary file in the same directory, synced and renamed over path. An
+// existing file keeps its mode and, where the caller may set it, its
+// ownership; perm applies to new files.
+// Symlinks are followed so that the link itself is preserved.
+func WriteFileAtomic(path string, content []byte, perm os.FileMode) (err error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	mode := perm
+	existing, statErr := os.Stat(path)
+	if statErr == nil {
+		mode = existing.Mode().Perm()
+	} else if !errors.Is(statErr, os.ErrNotExist) {
+		return statErr
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := writeFile(tmp, content); err != nil {
+		return err
+	}
+	if err := syncFile(tmp); err != nil {
 		return err
 	}
-	return os.WriteFile(path, content, 0o755)
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if existing != nil {
+		// Only root may give a file away. Anyone else who can replace the file
+		// ends up owning it rather than failing the write.
+		if err := chown(tmp, existing); err != nil && !errors.Is(err, os.ErrPermission) && !errors.Is(err, syscall.EINVAL) {
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir makes a completed rename durable. Not every platform supports
+// syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
 }
@@ -0,0 +1,122 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWriteFileAtomicPreservesMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "journal.json")
+	if err := WriteFileAtomic(path, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("two"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "two" || info.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected result: %q mode=%v", b, info.Mode().Perm())
+	}
+}
+
+func TestWriteFileAtomicFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles-tmux.conf")
+	link := filepath.Join(dir, ".tmux.conf")
+	if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(link, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected symlink to survive: %v", err)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "new" {
+		t.Fatalf("expected target to be updated, got %q", b)
+	}
+}
+
+func TestWriteFileAtomicLeavesOriginalOnFailure(t *testing.T) {
+	boom := errors.New("disk full")
+	failures := map[string]func(){
+		"write": func() {
+			writeFile = func(f *os.File, b []byte) (int, error) {
+				n, _ := f.Write(b[:len(b)/2])
+				return n, boom
+			}
+		},
+		"sync":   func() { syncFile = func(*os.File) error { return boom } },
+		"rename": func() { rename = func(string, string) error { return boom } },
+		"chown":  func() { chown = func(*os.File, os.FileInfo) error { return boom } },
+	}
+	for name, inject := range failures {
+		t.Run(name, func(t *testing.T) {
+			origWrite, origSync, origRename, origChown := writeFile, syncFile, rename, chown
+			t.Cleanup(func() { writeFile, syncFile, rename, chown = origWrite, origSync, origRename, origChown })
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "journal.json")
+			if err := os.WriteFile(path, []byte(`{"entries": []}`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			inject()
+			if err := WriteFileAtomic(path, []byte(`{"entries": [{"name": "hive"}]}`), 0o644); !errors.Is(err, boom) {
+				t.Fatalf("expected injected error, got %v", err)
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != `{"entries": []}` {
+				t.Fatalf("original file was modified: %q", b)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected temporary file to be cleaned up, found %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicIgnoresChownRefusal(t *testing.T) {
+	for _, refusal := range []error{syscall.EPERM, syscall.EINVAL} {
+		t.Run(refusal.Error(), func(t *testing.T) {
+			orig := chown
+			t.Cleanup(func() { chown = orig })
+			chown = func(f *os.File, _ os.FileInfo) error {
+				return &os.PathError{Op: "chown", Path: f.Name(), Err: refusal}
+			}
+
+			path := filepath.Join(t.TempDir(), "tmux.conf")
+			if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteFileAtomic(path, []byte("new"), 0o644); err != nil {
+				t.Fatalf("expected the write to succeed, got %v", err)
+			}
+			if b, _ := os.ReadFile(path); string(b) != "new" {
+				t.Fatalf("expected file to be replaced, got %q", b)
+			}
+		})
+	}
+}
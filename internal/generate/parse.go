@@ -0,0 +1,256 @@
+package generate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tforge/internal/shell"
+	"tforge/internal/snapshot"
+)
+
+const (
+	contentsPrefix = "cat -- "
+	contentsSuffix = `; exec "${SHELL:-/bin/sh}"`
+)
+
+// Parse reads a script produced by Script back into the session it was
+// generated from. Commands replayed with send-keys come back as the pane's
+// StartCommand, which Script replays verbatim.
+//
+// Window and pane indexes are read from the W<window> and P<window>_<pane>
+// variables that hold the ids tmux assigns. Scripts from earlier releases do
+// not record pane indexes; their panes are numbered from 0 unless a
+// select-pane or send-keys target shows that the window used a higher
+// pane-base-index.
+func Parse(script string) (snapshot.Session, error) {
+	var s snapshot.Session
+	var windows []*parsedWindow
+	var cur *parsedWindow
+
+	lines := strings.Split(script, "\n")
+	for n := 0; n < len(lines); n++ {
+		lineNo := n + 1
+		line := lines[n]
+		words, err := shell.Split(line)
+		for err == shell.ErrUnterminated && n+1 < len(lines) {
+			n++
+			line += "\n" + lines[n]
+			words, err = shell.Split(line)
+		}
+		if err != nil {
+			return snapshot.Session{}, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if name, ok := strings.CutPrefix(words[0], "SESSION="); ok && len(words) == 1 {
+			// Later assignments pick the name a conflict strategy restores as.
+			if s.Name == "" {
+				s.Name = name
+			}
+			continue
+		}
+		if words[0] == "read" && len(words) >= 4 && words[1] == "-r" {
+			// read -r W<window> P<window>_<pane> takes the ids of a new window.
+			w, p, err := paneIndex("$" + words[3])
+			if err != nil || cur == nil || len(cur.Panes) != 1 {
+				return snapshot.Session{}, fmt.Errorf("line %d: malformed read", lineNo)
+			}
+			cur.Index, cur.Panes[0].Index = w, p
+			continue
+		}
+		assign := ""
+		if v, ok := strings.CutSuffix(words[0], "=$(tmux"); ok && v != "" {
+			// VAR=$(tmux ...) keeps the ids of what the command creates.
+			assign = v
+			words[0] = "tmux"
+			words[len(words)-1] = strings.TrimSuffix(words[len(words)-1], ")")
+		}
+		if words[0] != "tmux" || len(words) < 2 {
+			continue
+		}
+		args := words[2:]
+		switch words[1] {
+		case "new-session", "new-window":
+			flags, rest := parseFlags(args, "dP")
+			// Older scripts name the session here rather than use $SESSION.
+			if words[1] == "new-session" && flags["s"] != "$SESSION" {
+				s.Name = flags["s"]
+			}
+			cur = &parsedWindow{Window: snapshot.Window{Index: -1, Name: flags["n"]}, sent: map[int]string{}}
+			cur.Panes = []snapshot.Pane{newPane(flags["c"], rest)}
+			windows = append(windows, cur)
+		case "split-window":
+			if cur == nil {
+				return snapshot.Session{}, fmt.Errorf("line %d: split-window before any window", lineNo)
+			}
+			flags, rest := parseFlags(args, "dhvP")
+			if _, err := windowIndex(flags["t"]); err != nil {
+				return snapshot.Session{}, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			pane := newPane(flags["c"], rest)
+			if assign != "" {
+				if _, pane.Index, err = paneIndex("$" + assign); err != nil {
+					return snapshot.Session{}, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+			}
+			cur.Panes = append(cur.Panes, pane)
+		case "select-layout":
+			flags, rest := parseFlags(args, "")
+			idx, err := windowIndex(flags["t"])
+			if err != nil || cur == nil || len(rest) != 1 {
+				return snapshot.Session{}, fmt.Errorf("line %d: malformed select-layout", lineNo)
+			}
+			cur.Index, cur.Layout = idx, rest[0]
+		case "send-keys":
+			// Current scripts type the command with -l and press Enter in a
+			// second call; older ones did both in one.
+			flags, rest := parseFlags(args, "l")
+			_, literal := flags["l"]
+			w, p, err := paneIndex(flags["t"])
+			switch {
+			case err != nil || cur == nil || cur.Index != w:
+				return snapshot.Session{}, fmt.Errorf("line %d: malformed send-keys", lineNo)
+			case literal && len(rest) == 1:
+				cur.sent[p] = rest[0]
+			case !literal && len(rest) == 1 && rest[0] == "Enter":
+			case !literal && len(rest) == 2 && rest[1] == "Enter":
+				cur.sent[p] = rest[0]
+			default:
+				return snapshot.Session{}, fmt.Errorf("line %d: malformed send-keys", lineNo)
+			}
+			cur.maxRef = max(cur.maxRef, p)
+		case "select-pane":
+			flags, _ := parseFlags(args, "")
+			w, p, err := paneIndex(flags["t"])
+			if err != nil || cur == nil || cur.Index != w {
+				return snapshot.Session{}, fmt.Errorf("line %d: malformed select-pane", lineNo)
+			}
+			cur.maxRef = max(cur.maxRef, p)
+			cur.ActivePane = p
+		case "select-window":
+			flags, _ := parseFlags(args, "")
+			if flags["t"] == `$SESSION` {
+				continue
+			}
+			idx, err := windowIndex(flags["t"])
+			if err != nil {
+				return snapshot.Session{}, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			s.ActiveWindow = idx
+		}
+	}
+	if len(windows) == 0 {
+		return snapshot.Session{}, fmt.Errorf("no tmux new-session command found; not a tforge script?")
+	}
+	for _, w := range windows {
+		if w.Index < 0 {
+			return snapshot.Session{}, fmt.Errorf("window %q has no select-layout", w.Name)
+		}
+		s.Windows = append(s.Windows, w.number())
+	}
+	return s, nil
+}
+
+// parsedWindow collects a window while its commands are read. Panes whose
+// index the script does not record keep -1 until the whole window has been
+// seen.
+type parsedWindow struct {
+	snapshot.Window
+	sent   map[int]string // commands sent to each pane index
+	maxRef int            // highest pane index used as a target
+}
+
+func (w *parsedWindow) number() snapshot.Window {
+	base := 0
+	if w.maxRef >= len(w.Panes) {
+		base = w.maxRef - len(w.Panes) + 1
+	}
+	for i := range w.Panes {
+		if w.Panes[i].Index < 0 {
+			w.Panes[i].Index = base + i
+		}
+		w.Panes[i].StartCommand = w.sent[w.Panes[i].Index]
+	}
+	return w.Window
+}
+
+func newPane(dir string, rest []string) snapshot.Pane {
+	p := snapshot.Pane{Index: -1, Path: dir}
+	if len(rest) == 1 {
+		cmd := rest[0]
+		if strings.HasPrefix(cmd, contentsPrefix) && strings.HasSuffix(cmd, contentsSuffix) {
+			quoted := strings.TrimSuffix(strings.TrimPrefix(cmd, contentsPrefix), contentsSuffix)
+			if words, err := shell.Split(quoted); err == nil && len(words) == 1 {
+				p.ContentsPath = words[0]
+			}
+		}
+	}
+	return p
+}
+
+// parseFlags separates tmux "-x value" options from positional arguments.
+// Letters in boolean take no value; "--" ends the options.
+func parseFlags(args []string, boolean string) (map[string]string, []string) {
+	flags := map[string]string{}
+	i := 0
+	for ; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			i++
+			break
+		}
+		if len(a) != 2 || a[0] != '-' {
+			break
+		}
+		if strings.IndexByte(boolean, a[1]) >= 0 {
+			flags[a[1:]] = ""
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			flags[a[1:]] = args[i]
+		}
+	}
+	return flags, args[i:]
+}
+
+// windowIndex reads the saved window index from a target: $W<index> in
+// current scripts, <session>:<index> in older ones.
+func windowIndex(target string) (int, error) {
+	if v, ok := strings.CutPrefix(target, "$W"); ok {
+		if idx, err := strconv.Atoi(v); err == nil {
+			return idx, nil
+		}
+	}
+	i := strings.LastIndexByte(target, ':')
+	idx, err := strconv.Atoi(target[i+1:])
+	if i < 0 || err != nil {
+		return 0, fmt.Errorf("invalid window target %q", target)
+	}
+	return idx, nil
+}
+
+// paneIndex reads the saved window and pane indexes from a target:
+// $P<window>_<pane> in current scripts, <session>:<window>.<pane> in older
+// ones.
+func paneIndex(target string) (window, pane int, err error) {
+	sep := byte('.')
+	if v, ok := strings.CutPrefix(target, "$P"); ok {
+		target, sep = "$W"+v, '_'
+	}
+	i := strings.LastIndexByte(target, sep)
+	if i < 0 {
+		return 0, 0, fmt.Errorf("invalid pane target %q", target)
+	}
+	window, err = windowIndex(target[:i])
+	if err != nil {
+		return 0, 0, err
+	}
+	pane, err = strconv.Atoi(target[i+1:])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid pane target %q", target)
+	}
+	return window, pane, nil
+}
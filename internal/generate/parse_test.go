@@ -0,0 +1,173 @@
+package generate
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tforge/internal/snapshot"
+)
+
+// adopted returns s as Parse sees it after a trip through Script: commands
+// become start commands and paths are cleaned.
+func adopted(s snapshot.Session) snapshot.Session {
+	out := s
+	out.Windows = nil
+	for _, w := range s.Windows {
+		nw := w
+		nw.Panes = nil
+		for _, p := range w.Panes {
+			nw.Panes = append(nw.Panes, snapshot.Pane{
+				Index:        p.Index,
+				Path:         filepath.Clean(p.Path),
+				StartCommand: ReplayCommand(p),
+				ContentsPath: p.ContentsPath,
+			})
+		}
+		out.Windows = append(out.Windows, nw)
+	}
+	return out
+}
+
+func roundTrip(t *testing.T, s snapshot.Session) {
+	t.Helper()
+	script, err := Script(s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(script)
+	if err != nil {
+		t.Fatalf("Parse: %v\n%s", err, script)
+	}
+	if want := adopted(s); !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	roundTrip(t, snapshot.Session{
+		Name:         "hive",
+		ActiveWindow: 2,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "abcd,80x24,0,0,1", Panes: []snapshot.Pane{{Index: 0, Path: "/workspace", ContentsPath: "/home/me/.tforge/0.0.txt"}}},
+			{Index: 2, Name: "dev", Layout: "l2", ActivePane: 1, Panes: []snapshot.Pane{
+				{Index: 0, Path: "/workspace", Command: "bash"},
+				{Index: 1, Path: "/srv/app", Command: "node", Argv: []string{"npm", "run", "dev"}},
+				{Index: 2, Path: "/var/log", StartCommand: "tail -f 'app log'"},
+			}},
+		},
+	})
+}
+
+func TestParseRoundTripAwkwardNames(t *testing.T) {
+	roundTrip(t, snapshot.Session{
+		Name: "it's a.b:c",
+		Windows: []snapshot.Window{{
+			Index:  0,
+			Name:   "multi\nline $(id)",
+			Layout: "x\"y",
+			Panes:  []snapshot.Pane{{Index: 0, Path: "/tmp/a b"}, {Index: 1, Path: "/tmp/'q'", Argv: []string{"tail", "-f", "it's"}}},
+		}},
+	})
+}
+
+func TestParseInfersPaneBaseIndex(t *testing.T) {
+	roundTrip(t, snapshot.Session{
+		Name:         "ops",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{{
+			Index:      1,
+			Name:       "main",
+			Layout:     "l",
+			ActivePane: 2,
+			Panes:      []snapshot.Pane{{Index: 1, Path: "/a"}, {Index: 2, Path: "/b"}},
+		}},
+	})
+}
+
+// With pane-base-index 1 and the first pane active, no target points past
+// the panes, so only the variable names give the indexes away.
+func TestParseRoundTripPaneBaseIndexWithEarlyActivePane(t *testing.T) {
+	roundTrip(t, snapshot.Session{
+		Name:         "ops",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{{
+			Index:      1,
+			Name:       "main",
+			Layout:     "l",
+			ActivePane: 1,
+			Panes: []snapshot.Pane{
+				{Index: 1, Path: "/a"},
+				{Index: 2, Path: "/b", StartCommand: "make watch"},
+				{Index: 3, Path: "/c"},
+			},
+		}},
+	})
+}
+
+func TestParseReadsScriptsFromEarlierReleases(t *testing.T) {
+	script := `#!/usr/bin/env bash
+set -euo pipefail
+
+SESSION=hive
+
+if tmux has-session -t "$SESSION" 2>/dev/null; then
+  tmux attach-session -t "$SESSION"
+  exit 0
+fi
+
+tmux new-session -d -s hive -n editor -c /workspace
+tmux split-window -t hive:1 -c /srv
+tmux select-layout -t hive:1 tiled
+tmux send-keys -t hive:1.1 'npm run dev' Enter
+tmux select-pane -t hive:1.0
+
+tmux select-window -t hive:1
+`
+	got, err := Parse(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := snapshot.Session{Name: "hive", ActiveWindow: 1, Windows: []snapshot.Window{{
+		Index: 1, Name: "editor", Layout: "tiled",
+		Panes: []snapshot.Pane{{Index: 0, Path: "/workspace"}, {Index: 1, Path: "/srv", StartCommand: "npm run dev"}},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseRejectsForeignScript(t *testing.T) {
+	_, err := Parse("#!/bin/sh\necho hello\n")
+	if err == nil || !strings.Contains(err.Error(), "not a tforge script") {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+}
+
+func FuzzParseRoundTrip(f *testing.F) {
+	f.Add("hive", "editor", "/workspace", "abcd,80x24,0,0,1", "tail -f x")
+	f.Add("dev's", "api|worker", "/srv/${HOME}/\"q\"", "x\ny", "echo 'a\nb'")
+	f.Fuzz(func(t *testing.T, session, window, path, layout, cmd string) {
+		for _, s := range []string{session, window, path, layout} {
+			if s == "" || strings.ContainsRune(s, 0) {
+				t.Skip("tmux arguments cannot be empty or contain NUL")
+			}
+		}
+		if cmd = strings.TrimSpace(cmd); strings.HasPrefix(cmd, "-") || strings.HasPrefix(layout, "-") {
+			t.Skip("tmux would read a leading dash as a flag")
+		}
+		roundTrip(t, snapshot.Session{
+			Name: session,
+			Windows: []snapshot.Window{{
+				Index:  0,
+				Name:   window,
+				Layout: layout,
+				Panes: []snapshot.Pane{
+					{Index: 0, Path: path, ContentsPath: path},
+					{Index: 1, Path: path, StartCommand: cmd},
+				},
+			}},
+		})
+	})
+}
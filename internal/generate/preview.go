@@ -0,0 +1,80 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"tforge/internal/layout"
+	"tforge/internal/snapshot"
+)
+
+// Preview draws every window of s as a box diagram width columns wide, with
+// each pane labelled by its index, directory and replayed command. Paths
+// under home are shortened to ~. Windows whose layout cannot be read are
+// drawn with their panes stacked evenly, as Zellij exports them.
+func Preview(s snapshot.Session, width int, home string) []string {
+	var out []string
+	for i, w := range s.Windows {
+		if i > 0 {
+			out = append(out, "")
+		}
+		header := fmt.Sprintf("%d: %s", w.Index, w.Name)
+		if w.Index == s.ActiveWindow {
+			header += " (active)"
+		}
+		root, err := layout.Parse(w.Layout)
+		if err != nil || len(root.Panes()) != len(w.Panes) {
+			root = layout.Stacked(len(w.Panes))
+			if w.Layout != "" && !strings.Contains(w.Layout, ",") {
+				header += fmt.Sprintf(" [%s]", w.Layout)
+			}
+		}
+		out = append(out, header)
+		if len(w.Panes) == 0 {
+			continue
+		}
+
+		labels := make([][]string, len(w.Panes))
+		for j, p := range w.Panes {
+			labels[j] = []string{fmt.Sprintf("%d %s", p.Index, shortPath(p.Path, home))}
+			if cmd := ReplayCommand(p); cmd != "" {
+				labels[j] = append(labels[j], cmd)
+			}
+		}
+		diagram, err := layout.Render(root, width, previewHeight(root, width), labels)
+		if err != nil {
+			// Too many panes to draw this narrow; list them instead.
+			for _, l := range labels {
+				out = append(out, "  "+strings.Join(l, "  "))
+			}
+			continue
+		}
+		out = append(out, diagram...)
+	}
+	return out
+}
+
+// previewHeight keeps the window's proportions in terminal cells. Layouts of
+// unknown size get two label lines and a border per pane.
+func previewHeight(root *layout.Cell, width int) int {
+	_, minHeight := root.MinSize()
+	height := 3*len(root.Panes()) + 1
+	if root.Width > 0 && root.Height > 0 {
+		height = (width-2)*root.Height/root.Width + 2
+	}
+	return max(height, minHeight+2, 4)
+}
+
+func shortPath(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rel
+	}
+	return path
+}
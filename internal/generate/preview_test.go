@@ -0,0 +1,67 @@
+package generate
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tforge/internal/layout"
+	"tforge/internal/snapshot"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("%s mismatch (run go test -update to accept):\n got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	s := snapshot.Session{
+		Name:         "hive",
+		ActiveWindow: 0,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "306f,160x40,0,0{80x40,0,0,0,79x40,81,0[79x20,81,0,1,79x19,81,21,2]}", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src", Command: "vim", Argv: []string{"vim", "main.go"}},
+				{Index: 1, Path: "/home/me/src", Command: "bash"},
+				{Index: 2, Path: "/home/me/src/web", StartCommand: "npm run dev"},
+			}},
+			{Index: 1, Name: "logs", Layout: "tiled", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/var/log"},
+				{Index: 1, Path: "/var/log", Argv: []string{"tail", "-f", "syslog"}},
+			}},
+		},
+	}
+	out := strings.Join(Preview(s, 48, "/home/me"), "\n") + "\n"
+	golden(t, "hive.preview.txt", []byte(out))
+}
+
+func TestPreviewListsPanesThatDoNotFit(t *testing.T) {
+	w := snapshot.Window{Index: 0, Name: "many"}
+	var cells []string
+	for i := 0; i < 30; i++ {
+		w.Panes = append(w.Panes, snapshot.Pane{Index: i, Path: "/tmp"})
+		cells = append(cells, fmt.Sprintf("2x24,%d,0,%d", 3*i, i))
+	}
+	body := "89x24,0,0{" + strings.Join(cells, ",") + "}"
+	w.Layout = fmt.Sprintf("%04x,%s", layout.Checksum(body), body)
+	out := Preview(snapshot.Session{Name: "many", Windows: []snapshot.Window{w}}, 40, "")
+	if len(out) != 31 || out[30] != "  29 /tmp" {
+		t.Fatalf("expected a pane list, got %q", out)
+	}
+}
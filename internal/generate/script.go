@@ -5,57 +5,196 @@ import (
 	"path/filepath"
 	"strings"
 
+	"tforge/internal/onconflict"
+	"tforge/internal/shell"
 	"tforge/internal/snapshot"
 )
 
-func Script(s snapshot.Session) (string, error) {
+// Script renders s as a standalone bash script. onConflict is what the script
+// does when the session is already running; TFORGE_ON_CONFLICT overrides it
+// when the script is run.
+func Script(s snapshot.Session, onConflict onconflict.Strategy) (string, error) {
 	if len(s.Windows) == 0 {
 		return "", fmt.Errorf("session has no windows")
 	}
+	if onConflict == "" {
+		onConflict = onconflict.Attach
+	}
 
 	var b strings.Builder
 	b.WriteString("#!/usr/bin/env bash\n")
 	b.WriteString("set -euo pipefail\n\n")
-	b.WriteString(fmt.Sprintf("SESSION=%q\n", s.Name))
+	b.WriteString(fmt.Sprintf("SESSION=%s\n", shell.Quote(s.Name)))
+	b.WriteString(fmt.Sprintf("ON_CONFLICT=\"${TFORGE_ON_CONFLICT:-%s}\"\n", onConflict))
+	b.WriteString("TARGET=$SESSION\n")
+	b.WriteString("EXISTING=\n")
 	b.WriteString("\n")
-	b.WriteString("if tmux has-session -t \"$SESSION\" 2>/dev/null; then\n")
-	b.WriteString("  WINDOWS=$(tmux list-windows -t \"$SESSION\" 2>/dev/null | wc -l | tr -d ' ')\n")
-	b.WriteString("  PANES=$(tmux list-panes -t \"$SESSION\" 2>/dev/null | wc -l | tr -d ' ')\n")
-	b.WriteString("  if [ \"${WINDOWS:-0}\" = \"1\" ] && [ \"${PANES:-0}\" = \"1\" ]; then\n")
-	b.WriteString("    tmux kill-session -t \"$SESSION\"\n")
+	b.WriteString("attach() {\n")
+	b.WriteString("  if [ -n \"${TMUX:-}\" ]; then\n")
+	b.WriteString("    tmux switch-client -t \"$1\"\n")
 	b.WriteString("  else\n")
-	b.WriteString("    if [ -n \"${TMUX:-}\" ]; then\n")
-	b.WriteString("      tmux switch-client -t \"$SESSION\"\n")
+	b.WriteString("    tmux attach-session -t \"$1\"\n")
+	b.WriteString("  fi\n")
+	b.WriteString("}\n\n")
+	b.WriteString("# missing reports whether a merge should add the window named $1. A\n")
+	b.WriteString("# window without a name cannot be matched, so it is always added.\n")
+	b.WriteString("missing() {\n")
+	b.WriteString("  [ -z \"$1\" ] || ! printf '%s\\n' \"$EXISTING\" | grep -Fxq -- \"$1\"\n")
+	b.WriteString("}\n\n")
+	b.WriteString("# Session targets start with = so that tmux matches names exactly rather\n")
+	b.WriteString("# than taking another session that starts with the same name.\n")
+	b.WriteString("if tmux has-session -t \"=$SESSION\" 2>/dev/null; then\n")
+	b.WriteString("  case \"$ON_CONFLICT\" in\n")
+	b.WriteString("  attach)\n")
+	b.WriteString("    WINDOWS=$(tmux list-windows -t \"=$SESSION\" 2>/dev/null | wc -l | tr -d ' ')\n")
+	b.WriteString("    PANES=$(tmux list-panes -t \"=$SESSION:\" 2>/dev/null | wc -l | tr -d ' ')\n")
+	b.WriteString("    if [ \"${WINDOWS:-0}\" = \"1\" ] && [ \"${PANES:-0}\" = \"1\" ]; then\n")
+	b.WriteString("      tmux kill-session -t \"=$SESSION\"\n")
 	b.WriteString("    else\n")
-	b.WriteString("      tmux attach-session -t \"$SESSION\"\n")
+	b.WriteString("      attach \"$SESSION\"\n")
+	b.WriteString("      exit 0\n")
 	b.WriteString("    fi\n")
-	b.WriteString("    exit 0\n")
-	b.WriteString("  fi\n")
+	b.WriteString("    ;;\n")
+	b.WriteString("  replace)\n")
+	b.WriteString("    tmux kill-session -t \"=$SESSION\"\n")
+	b.WriteString("    ;;\n")
+	b.WriteString("  rename)\n")
+	b.WriteString("    N=2\n")
+	b.WriteString("    while tmux has-session -t \"=$TARGET-$N\" 2>/dev/null; do N=$((N + 1)); done\n")
+	b.WriteString("    SESSION=$TARGET-$N\n")
+	b.WriteString("    ;;\n")
+	b.WriteString("  merge)\n")
+	b.WriteString("    # Build the saved session aside, then move the missing windows over.\n")
+	b.WriteString("    EXISTING=$(tmux list-windows -t \"=$TARGET\" -F '#{window_name}')\n")
+	b.WriteString("    SESSION=$TARGET-tforge-$$\n")
+	b.WriteString("    ;;\n")
+	b.WriteString("  fail)\n")
+	b.WriteString("    echo \"tmux session $SESSION already exists\" >&2\n")
+	b.WriteString("    exit 1\n")
+	b.WriteString("    ;;\n")
+	b.WriteString("  *)\n")
+	b.WriteString("    echo \"unknown conflict strategy $ON_CONFLICT (supported: attach, replace, rename, merge, fail)\" >&2\n")
+	b.WriteString("    exit 2\n")
+	b.WriteString("    ;;\n")
+	b.WriteString("  esac\n")
 	b.WriteString("fi\n\n")
 
+	b.WriteString("# Windows and panes are targeted by the ids tmux assigns them, so the\n")
+	b.WriteString("# base-index and pane-base-index options do not matter.\n")
 	for i, w := range s.Windows {
 		if len(w.Panes) == 0 {
 			return "", fmt.Errorf("window %q has no panes", w.Name)
 		}
-		firstPath := filepath.Clean(w.Panes[0].Path)
+		firstPath := shell.Quote(filepath.Clean(w.Panes[0].Path))
 		if i == 0 {
-			b.WriteString(fmt.Sprintf("tmux new-session -d -s %q -n %q -c %q\n", s.Name, w.Name, firstPath))
+			b.WriteString(fmt.Sprintf("IDS=$(tmux new-session -d -s \"$SESSION\" -n %s -c %s -P -F '#{window_id} #{pane_id}'%s)\n", shell.Quote(w.Name), firstPath, contentsArg(w.Panes[0])))
 		} else {
-			b.WriteString(fmt.Sprintf("tmux new-window -t %q -n %q -c %q\n", s.Name, w.Name, firstPath))
+			b.WriteString(fmt.Sprintf("IDS=$(tmux new-window -t \"$SESSION:\" -n %s -c %s -P -F '#{window_id} #{pane_id}'%s)\n", shell.Quote(w.Name), firstPath, contentsArg(w.Panes[0])))
+		}
+		b.WriteString(fmt.Sprintf("read -r %s %s <<<\"$IDS\"\n", windowVar(w.Index), paneVar(w.Index, w.Panes[0].Index)))
+		windowTarget := windowTarget(w.Index)
+		for _, pane := range w.Panes[1:] {
+			b.WriteString(fmt.Sprintf("%s=$(tmux split-window -t %s -c %s -P -F '#{pane_id}'%s)\n", paneVar(w.Index, pane.Index), windowTarget, shell.Quote(filepath.Clean(pane.Path)), contentsArg(pane)))
 		}
-		for paneIdx := 1; paneIdx < len(w.Panes); paneIdx++ {
-			pane := w.Panes[paneIdx]
-			b.WriteString(fmt.Sprintf("tmux split-window -t %q:%d -c %q\n", s.Name, w.Index, filepath.Clean(pane.Path)))
+		b.WriteString(fmt.Sprintf("tmux select-layout -t %s %s\n", windowTarget, shell.Quote(w.Layout)))
+		var replay []string
+		active := w.Panes[0].Index
+		for _, pane := range w.Panes {
+			if cmd := ReplayCommand(pane); cmd != "" {
+				// -l types the command literally rather than as key names.
+				target := paneTarget(w.Index, pane.Index)
+				replay = append(replay, fmt.Sprintf("  tmux send-keys -l -t %s -- %s\n", target, shell.Quote(cmd)))
+				replay = append(replay, fmt.Sprintf("  tmux send-keys -t %s Enter\n", target))
+			}
+			if pane.Index == w.ActivePane {
+				active = pane.Index
+			}
+		}
+		if len(replay) > 0 {
+			// A merge drops windows the running session already has, so their
+			// commands must not start.
+			b.WriteString(fmt.Sprintf("if missing %s; then\n", shell.Quote(w.Name)))
+			b.WriteString(strings.Join(replay, ""))
+			b.WriteString("fi\n")
+		}
+		b.WriteString(fmt.Sprintf("tmux select-pane -t %s\n", paneTarget(w.Index, active)))
+	}
+	active := s.Windows[0].Index
+	for _, w := range s.Windows {
+		if w.Index == s.ActiveWindow {
+			active = w.Index
 		}
-		b.WriteString(fmt.Sprintf("tmux select-layout -t %q:%d %q\n", s.Name, w.Index, w.Layout))
-		b.WriteString(fmt.Sprintf("tmux select-pane -t %q:%d.%d\n", s.Name, w.Index, w.ActivePane))
 	}
 	b.WriteString("\n")
-	b.WriteString(fmt.Sprintf("tmux select-window -t %q:%d\n", s.Name, s.ActiveWindow))
-	b.WriteString("if [ -n \"${TMUX:-}\" ]; then\n")
-	b.WriteString("  tmux switch-client -t \"$SESSION\"\n")
-	b.WriteString("else\n")
-	b.WriteString("  tmux attach-session -t \"$SESSION\"\n")
+	b.WriteString(fmt.Sprintf("tmux select-window -t %s\n", windowTarget(active)))
+	b.WriteString("\n")
+	b.WriteString("if [ \"$ON_CONFLICT\" = merge ] && [ \"$SESSION\" != \"$TARGET\" ]; then\n")
+	for _, w := range s.Windows {
+		b.WriteString(fmt.Sprintf("  if missing %s; then tmux move-window -d -s %s -t \"$TARGET:\"; fi\n", shell.Quote(w.Name), windowTarget(w.Index)))
+	}
+	b.WriteString("  # Moving the last window out ends the session by itself.\n")
+	b.WriteString("  tmux kill-session -t \"=$SESSION\" 2>/dev/null || true\n")
+	b.WriteString("  SESSION=$TARGET\n")
 	b.WriteString("fi\n")
+	b.WriteString("attach \"$SESSION\"\n")
 	return b.String(), nil
 }
+
+// windowVar and paneVar name the shell variables holding the ids of the saved
+// window and pane with the given indexes. Parse reads the indexes back from
+// them.
+func windowVar(window int) string {
+	return fmt.Sprintf("W%d", window)
+}
+
+func paneVar(window, pane int) string {
+	return fmt.Sprintf("P%d_%d", window, pane)
+}
+
+func windowTarget(window int) string {
+	return fmt.Sprintf("\"$%s\"", windowVar(window))
+}
+
+func paneTarget(window, pane int) string {
+	return fmt.Sprintf("\"$%s\"", paneVar(window, pane))
+}
+
+func contentsArg(p snapshot.Pane) string {
+	cmd := ContentsCommand(p)
+	if cmd == "" {
+		return ""
+	}
+	return " " + shell.Quote(cmd)
+}
+
+// ContentsCommand returns the shell command a restored pane is started with to
+// print its saved scrollback before handing over to the user's shell, or ""
+// if no scrollback was saved.
+func ContentsCommand(p snapshot.Pane) string {
+	if p.ContentsPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("cat -- %s; exec \"${SHELL:-/bin/sh}\"", shell.Quote(p.ContentsPath))
+}
+
+// ReplayCommand returns the command line to type into a restored pane, or ""
+// when the pane was running a bare shell.
+func ReplayCommand(p snapshot.Pane) string {
+	if len(p.Argv) > 0 && !IsShell(p.Argv[0]) {
+		return shell.Join(p.Argv)
+	}
+	if IsShell(p.Command) {
+		return ""
+	}
+	return strings.TrimSpace(p.StartCommand)
+}
+
+// IsShell reports whether command names an interactive shell, as opposed to
+// a program worth starting again on restore.
+func IsShell(command string) bool {
+	switch strings.TrimPrefix(filepath.Base(command), "-") {
+	case "sh", "bash", "zsh", "fish", "dash", "ksh", "mksh", "tcsh", "csh", "nu", "xonsh", "elvish":
+		return true
+	}
+	return false
+}
@@ -1,9 +1,13 @@
 package generate
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"tforge/internal/onconflict"
 	"tforge/internal/snapshot"
 )
 
@@ -19,14 +23,343 @@ func TestScriptIncludesIdempotentAttachFlow(t *testing.T) {
 			Panes:      []snapshot.Pane{{Index: 0, Path: "/workspace"}, {Index: 1, Path: "/workspace"}},
 		}},
 	}
-	out, err := Script(s)
+	out, err := Script(s, "")
 	if err != nil {
 		t.Fatal(err)
 	}
-	checks := []string{"tmux has-session -t \"$SESSION\"", "tmux switch-client -t \"$SESSION\"", "tmux attach-session -t \"$SESSION\"", "tmux new-session -d -s \"hive\" -n \"editor\" -c \"/workspace\"", "tmux kill-session -t \"$SESSION\""}
+	checks := []string{"tmux has-session -t \"=$SESSION\"", "tmux switch-client -t \"$1\"", "tmux attach-session -t \"$1\"", "attach \"$SESSION\"", "tmux new-session -d -s \"$SESSION\" -n editor -c /workspace", "tmux kill-session -t \"=$SESSION\""}
 	for _, c := range checks {
 		if !strings.Contains(out, c) {
 			t.Fatalf("expected generated script to contain %q", c)
 		}
 	}
 }
+
+func TestScriptReplaysPaneCommands(t *testing.T) {
+	s := snapshot.Session{
+		Name: "hive",
+		Windows: []snapshot.Window{{
+			Index:  0,
+			Name:   "dev",
+			Layout: "abcd",
+			Panes: []snapshot.Pane{
+				{Index: 0, Path: "/workspace", Command: "bash"},
+				{Index: 1, Path: "/workspace", Command: "node", Argv: []string{"npm", "run", "dev"}},
+				{Index: 2, Path: "/workspace", Command: "tail", Argv: []string{"tail", "-f", "app log"}},
+			},
+		}},
+	}
+	out, err := Script(s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, `send-keys -l -t "$P0_0"`) {
+		t.Fatal("did not expect a command for an idle shell pane")
+	}
+	for _, c := range []string{`tmux send-keys -l -t "$P0_1" -- 'npm run dev'`, `tmux send-keys -t "$P0_1" Enter`, `tmux send-keys -l -t "$P0_2" -- 'tail -f '\''app log'\'''`} {
+		if !strings.Contains(out, c) {
+			t.Fatalf("expected generated script to contain %q\n%s", c, out)
+		}
+	}
+}
+
+func TestScriptTypesKeyNamesLiterally(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	s := conflictSession()
+	s.Windows[0].Panes[0].Argv = []string{"Enter"}
+	s.Windows[1].Panes[0].Argv = []string{"C-c"}
+	script, err := Script(s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := callsOf(runScript(t, script))
+	for _, c := range []string{"send-keys -l -t %1 -- Enter\nsend-keys -t %1 Enter", "send-keys -l -t %2 -- C-c\nsend-keys -t %2 Enter"} {
+		if !strings.Contains(got, c) {
+			t.Errorf("expected %q in calls:\n%s", c, got)
+		}
+	}
+	parsed, err := Parse(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd := parsed.Windows[0].Panes[0].StartCommand; cmd != "Enter" {
+		t.Fatalf("expected Parse to read the command back, got %q", cmd)
+	}
+}
+
+func TestScriptReplaysPaneContents(t *testing.T) {
+	s := snapshot.Session{
+		Name: "hive",
+		Windows: []snapshot.Window{{
+			Index:  0,
+			Name:   "editor",
+			Layout: "abcd",
+			Panes: []snapshot.Pane{
+				{Index: 0, Path: "/workspace", ContentsPath: "/home/me/.tforge/sessions/hive/0.0.txt"},
+				{Index: 1, Path: "/workspace"},
+			},
+		}},
+	}
+	out, err := Script(s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `IDS=$(tmux new-session -d -s "$SESSION" -n editor -c /workspace -P -F '#{window_id} #{pane_id}' 'cat -- /home/me/.tforge/sessions/hive/0.0.txt; exec "${SHELL:-/bin/sh}"')`
+	if !strings.Contains(out, want+"\n") {
+		t.Fatalf("expected generated script to contain %q\n%s", want, out)
+	}
+	if !strings.Contains(out, `P0_1=$(tmux split-window -t "$W0" -c /workspace -P -F '#{pane_id}')`+"\n") {
+		t.Fatal("expected pane without contents to start a plain shell")
+	}
+}
+
+// fakeTmuxScript records each tmux invocation as NUL-terminated arguments
+// followed by an empty record. Only the session named by TMUX_EXISTING
+// exists; it has the windows listed in TMUX_WINDOWS, one pane each. Like
+// tmux, has-session takes a prefix of the name unless the target starts with
+// =. New windows and panes get ids numbered from 1, @1 with %1 and so on.
+const fakeTmuxScript = `#!/usr/bin/env bash
+if [ "$1" = has-session ]; then
+  case "$3" in
+  =*) [ "${3#=}" = "${TMUX_EXISTING:-}" ] ;;
+  *) [ -n "${TMUX_EXISTING:-}" ] && [ "${TMUX_EXISTING#"$3"}" != "$TMUX_EXISTING" ] ;;
+  esac
+  exit
+fi
+for a in "$@"; do printf '%s\0' "$a"; done >>"$TMUX_LOG"
+printf '\0' >>"$TMUX_LOG"
+next() { n=$(($(cat "$TMUX_LOG.id" 2>/dev/null || echo 0) + 1)); echo "$n" >"$TMUX_LOG.id"; }
+case "$1" in
+list-windows | list-panes) printf '%s' "${TMUX_WINDOWS:-}" ;;
+new-session | new-window) next; echo "@$n %$n" ;;
+split-window) next; echo "%$n" ;;
+esac
+`
+
+// runScript executes a generated script against a fake tmux and returns the
+// argv of every tmux call it made.
+func runScript(t *testing.T, script string, env ...string) [][]string {
+	t.Helper()
+	calls, out, err := execScript(t, script, env...)
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	return calls
+}
+
+func execScript(t *testing.T, script string, env ...string) ([][]string, string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(fakeTmuxScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "log")
+	cmd := exec.Command("bash", "--norc", "--noprofile", "-c", script)
+	cmd.Env = append([]string{"PATH=" + dir + ":/usr/bin:/bin", "TMUX_LOG=" + log, "TMUX="}, env...)
+	out, runErr := cmd.CombinedOutput()
+	b, err := os.ReadFile(log)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var calls [][]string
+	var cur []string
+	for _, field := range strings.Split(string(b), "\x00") {
+		if field == "" {
+			if cur != nil {
+				calls = append(calls, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, field)
+	}
+	return calls, string(out), runErr
+}
+
+func conflictSession() snapshot.Session {
+	return snapshot.Session{
+		Name: "hive",
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "l0", Panes: []snapshot.Pane{{Index: 0, Path: "/src", Argv: []string{"vim"}}}},
+			{Index: 1, Name: "logs", Layout: "l1", Panes: []snapshot.Pane{{Index: 0, Path: "/var/log", Argv: []string{"tail", "-f", "syslog"}}}},
+		},
+	}
+}
+
+// callsOf joins each tmux call into one line for easier matching.
+func callsOf(calls [][]string) string {
+	lines := make([]string, len(calls))
+	for i, c := range calls {
+		lines[i] = strings.Join(c, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestScriptConflictStrategies(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	existing := []string{"TMUX_EXISTING=hive", "TMUX_WINDOWS=editor\nshell\n"}
+	cases := []struct {
+		conflict onconflict.Strategy
+		want     []string
+		not      []string
+	}{
+		{onconflict.Attach, []string{"list-windows -t =hive", "attach-session -t hive"}, []string{"new-session", "kill-session"}},
+		{onconflict.Replace, []string{"kill-session -t =hive", "new-session -d -s hive -n editor -c /src", "attach-session -t hive"}, nil},
+		{onconflict.Rename, []string{"new-session -d -s hive-2 -n editor", "send-keys -l -t %1 -- vim", "send-keys -t %1 Enter", "attach-session -t hive-2"}, []string{"kill-session"}},
+		{onconflict.Merge, []string{"list-windows -t =hive -F #{window_name}", "new-session -d -s hive-tforge-", "move-window -d -s @2 -t hive:", "attach-session -t hive"}, []string{"kill-session -t =hive\n"}},
+	}
+	for _, c := range cases {
+		script, err := Script(conflictSession(), c.conflict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := callsOf(runScript(t, script, existing...))
+		for _, w := range c.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: expected %q in calls:\n%s", c.conflict, w, got)
+			}
+		}
+		for _, w := range c.not {
+			if strings.Contains(got, w) {
+				t.Errorf("%s: did not expect %q in calls:\n%s", c.conflict, w, got)
+			}
+		}
+	}
+}
+
+func TestScriptMergeOnlyMovesMissingWindows(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	script, err := Script(conflictSession(), onconflict.Merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var moves, sent []string
+	for _, c := range runScript(t, script, "TMUX_EXISTING=hive", "TMUX_WINDOWS=editor\nshell\n") {
+		switch c[0] {
+		case "move-window":
+			moves = append(moves, c[3])
+		case "send-keys":
+			if c[1] == "-l" {
+				sent = append(sent, c[5])
+			}
+		}
+	}
+	if strings.Join(moves, ",") != "@2" || strings.Join(sent, ",") != "tail -f syslog" {
+		t.Fatalf("expected only the logs window to be started and moved, got moves=%q sent=%q", moves, sent)
+	}
+}
+
+func TestScriptMergeAddsUnnamedWindows(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	s := conflictSession()
+	s.Windows[1].Name = ""
+	script, err := Script(s, onconflict.Merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The running session has an unnamed window too, but names are all a
+	// merge can match on, so the saved one is still added.
+	var moves []string
+	for _, c := range runScript(t, script, "TMUX_EXISTING=hive", "TMUX_WINDOWS=\neditor\n") {
+		if c[0] == "move-window" {
+			moves = append(moves, c[3])
+		}
+	}
+	if strings.Join(moves, ",") != "@2" {
+		t.Fatalf("expected the unnamed window to be moved, got %q", moves)
+	}
+}
+
+func TestScriptMatchesSessionNamesExactly(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	for _, conflict := range []onconflict.Strategy{onconflict.Attach, onconflict.Rename} {
+		script, err := Script(conflictSession(), conflict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := callsOf(runScript(t, script, "TMUX_EXISTING=hive-2x"))
+		if !strings.HasPrefix(got, "new-session -d -s hive -n editor") {
+			t.Errorf("%s: expected hive-2x not to count as hive, got:\n%s", conflict, got)
+		}
+	}
+}
+
+func TestScriptFailsOnConflict(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	script, err := Script(conflictSession(), onconflict.Fail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls, out, err := execScript(t, script, "TMUX_EXISTING=hive")
+	if err == nil || len(calls) != 0 || !strings.Contains(out, "hive already exists") {
+		t.Fatalf("expected the script to fail untouched, got err=%v calls=%q out=%q", err, calls, out)
+	}
+	// The strategy baked into the script can be overridden when running it.
+	got := callsOf(runScript(t, script, "TMUX_EXISTING=hive", "TFORGE_ON_CONFLICT=replace"))
+	if !strings.HasPrefix(got, "kill-session -t =hive\nnew-session") {
+		t.Fatalf("expected TFORGE_ON_CONFLICT=replace to win, got:\n%s", got)
+	}
+}
+
+func FuzzScriptQuoting(f *testing.F) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		f.Skip("bash not available")
+	}
+	f.Add("hive", "editor", "/workspace", "abcd,80x24,0,0,1")
+	f.Add("$(touch pwned)", "`id`", "/tmp/a|b $x/it's", "a;b")
+	f.Add("dev's", "api|worker", "/srv/${HOME}/\"q\"", "x\ny")
+	f.Fuzz(func(t *testing.T, session, window, path, layout string) {
+		for _, s := range []string{session, window, path, layout} {
+			if s == "" || strings.ContainsRune(s, 0) {
+				t.Skip("tmux arguments cannot be empty or contain NUL")
+			}
+		}
+		s := snapshot.Session{
+			Name: session,
+			Windows: []snapshot.Window{{
+				Index:  0,
+				Name:   window,
+				Layout: layout,
+				Panes: []snapshot.Pane{
+					{Index: 0, Path: path, ContentsPath: path},
+					{Index: 1, Path: path, Argv: []string{"tail", "-f", path}},
+				},
+			}},
+		}
+		script, err := Script(s, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls := runScript(t, script)
+		want := [][]string{
+			{"new-session", "-d", "-s", session, "-n", window, "-c", filepath.Clean(path), "-P", "-F", "#{window_id} #{pane_id}", ContentsCommand(s.Windows[0].Panes[0])},
+			{"split-window", "-t", "@1", "-c", filepath.Clean(path), "-P", "-F", "#{pane_id}"},
+			{"select-layout", "-t", "@1", layout},
+			{"send-keys", "-l", "-t", "%2", "--", ReplayCommand(s.Windows[0].Panes[1])},
+			{"send-keys", "-t", "%2", "Enter"},
+			{"select-pane", "-t", "%1"},
+			{"select-window", "-t", "@1"},
+			{"attach-session", "-t", session},
+		}
+		if len(calls) != len(want) {
+			t.Fatalf("expected %d tmux calls, got %d: %q", len(want), len(calls), calls)
+		}
+		for i := range want {
+			if strings.Join(calls[i], "\x00") != strings.Join(want[i], "\x00") {
+				t.Fatalf("call %d: got %q, want %q", i, calls[i], want[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,121 @@
+// Package importer converts layouts saved by other tmux tools into snapshot
+// sessions.
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tforge/internal/snapshot"
+)
+
+// Resurrect is the content of one tmux-resurrect save file.
+type Resurrect struct {
+	Sessions []snapshot.Session
+	// Current is the session the client was attached to when it was saved.
+	Current string
+}
+
+// ParseResurrect reads a tmux-resurrect save file. Lines are tab-separated and
+// start with their type; fields that may be empty carry a leading ':'.
+//
+//	pane     session window window_active :window_flags pane_index [pane_title] :dir pane_active command :full_command
+//	window   session window :name window_active :window_flags layout [:automatic_rename]
+//	state    client_session client_last_session
+//
+// pane_title was added in later releases and is optional. Sessions are
+// returned in the order they first appear.
+func ParseResurrect(r io.Reader) (Resurrect, error) {
+	var res Resurrect
+	sessions := map[string]*snapshot.Session{}
+	var order []string
+	session := func(name string) *snapshot.Session {
+		if s, ok := sessions[name]; ok {
+			return s
+		}
+		s := &snapshot.Session{Name: name}
+		sessions[name] = s
+		order = append(order, name)
+		return s
+	}
+	window := func(s *snapshot.Session, index int) *snapshot.Window {
+		for i := range s.Windows {
+			if s.Windows[i].Index == index {
+				return &s.Windows[i]
+			}
+		}
+		s.Windows = append(s.Windows, snapshot.Window{Index: index})
+		return &s.Windows[len(s.Windows)-1]
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Split(sc.Text(), "\t")
+		switch fields[0] {
+		case "pane":
+			if len(fields) == 10 {
+				// Saved before pane titles were recorded.
+				fields = append(fields[:6], append([]string{""}, fields[6:]...)...)
+			}
+			if len(fields) != 11 {
+				return Resurrect{}, fmt.Errorf("line %d: pane line has %d fields", n, len(fields))
+			}
+			winIdx, err1 := strconv.Atoi(fields[2])
+			paneIdx, err2 := strconv.Atoi(fields[5])
+			if err1 != nil || err2 != nil {
+				return Resurrect{}, fmt.Errorf("line %d: invalid window or pane index", n)
+			}
+			s := session(fields[1])
+			w := window(s, winIdx)
+			p := snapshot.Pane{
+				Index:        paneIdx,
+				Path:         strings.ReplaceAll(strings.TrimPrefix(fields[7], ":"), `\ `, " "),
+				Command:      fields[9],
+				StartCommand: strings.TrimPrefix(fields[10], ":"),
+			}
+			w.Panes = append(w.Panes, p)
+			if fields[8] == "1" {
+				w.ActivePane = paneIdx
+			}
+		case "window":
+			if len(fields) != 7 && len(fields) != 8 {
+				return Resurrect{}, fmt.Errorf("line %d: window line has %d fields", n, len(fields))
+			}
+			winIdx, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return Resurrect{}, fmt.Errorf("line %d: invalid window index", n)
+			}
+			s := session(fields[1])
+			w := window(s, winIdx)
+			w.Name = strings.TrimPrefix(fields[3], ":")
+			w.Layout = fields[6]
+			if fields[4] == "1" {
+				s.ActiveWindow = winIdx
+			}
+		case "state":
+			if len(fields) > 1 {
+				res.Current = fields[1]
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Resurrect{}, err
+	}
+	for _, name := range order {
+		s := sessions[name]
+		for _, w := range s.Windows {
+			if len(w.Panes) == 0 {
+				return Resurrect{}, fmt.Errorf("session %s: window %d has no panes", name, w.Index)
+			}
+		}
+		res.Sessions = append(res.Sessions, *s)
+	}
+	if len(res.Sessions) == 0 {
+		return Resurrect{}, fmt.Errorf("no sessions found; not a tmux-resurrect save file?")
+	}
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tforge/internal/snapshot"
+)
+
+func TestParseResurrect(t *testing.T) {
+	f, err := os.Open("testdata/resurrect.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ParseResurrect(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Current != "ops" {
+		t.Fatalf("unexpected current session %q", res.Current)
+	}
+	want := []snapshot.Session{
+		{
+			Name: "hive",
+			Windows: []snapshot.Window{
+				{Index: 0, Name: "editor", Layout: "b25f,160x40,0,0{80x40,0,0,0,79x40,81,0,1}", Panes: []snapshot.Pane{
+					{Index: 0, Path: "/home/me/src", Command: "vim", StartCommand: "vim main.go"},
+					{Index: 1, Path: "/home/me/my src", Command: "bash"},
+				}},
+				{Index: 1, Name: "logs", Layout: "9a3c,160x40,0,0,2", Panes: []snapshot.Pane{
+					{Index: 0, Path: "/var/log", Command: "tail", StartCommand: "tail -f syslog"},
+				}},
+			},
+		},
+		{
+			Name:         "ops",
+			ActiveWindow: 1,
+			Windows: []snapshot.Window{
+				{Index: 1, Name: "main", Layout: "c2f1,160x40,0,0,3", ActivePane: 1, Panes: []snapshot.Pane{
+					{Index: 1, Path: "/srv", Command: "zsh"},
+				}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(res.Sessions, want) {
+		t.Fatalf("unexpected sessions:\n got %+v\nwant %+v", res.Sessions, want)
+	}
+}
+
+func TestParseResurrectRejectsMalformedInput(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"pane\thive\tx\t1\t:*\t0\t:/tmp\t1\tbash\t:\n",
+		"window\thive\t0\t:editor\n",
+		"window\thive\t0\t:editor\t1\t:*\tlayout\n",
+	} {
+		if _, err := ParseResurrect(strings.NewReader(in)); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
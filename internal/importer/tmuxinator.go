@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"tforge/internal/snapshot"
+)
+
+// ParseTmuxinator reads a tmuxinator project file. Window roots are relative
+// to the project root; pre_window commands run ahead of each pane's own.
+// Projects that use ERB templating cannot be read without Ruby.
+func ParseTmuxinator(b []byte, home string) (snapshot.Session, error) {
+	if bytes.Contains(b, []byte("<%")) {
+		return snapshot.Session{}, fmt.Errorf("tmuxinator ERB templates are not supported; render the project first (tmuxinator debug)")
+	}
+	var raw any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return snapshot.Session{}, err
+	}
+	doc, ok := asMap(raw)
+	if !ok {
+		return snapshot.Session{}, fmt.Errorf("not a tmuxinator project: expected a mapping")
+	}
+	s := snapshot.Session{Name: asString(first(doc, "name", "project_name"))}
+	if s.Name == "" {
+		return snapshot.Session{}, fmt.Errorf("not a tmuxinator project: missing name")
+	}
+	root := resolveDir(asString(first(doc, "root", "project_root")), home, home)
+	before := asCommands(first(doc, "pre_window", "pre_tab"))
+	startupWindow := asString(doc["startup_window"])
+	startupPane, _ := strconv.Atoi(asString(doc["startup_pane"]))
+
+	windows, _ := first(doc, "windows", "tabs").([]any)
+	if len(windows) == 0 {
+		return snapshot.Session{}, fmt.Errorf("tmuxinator project %s has no windows", s.Name)
+	}
+	for wi, rw := range windows {
+		name, value, ok := singleEntry(rw)
+		if !ok {
+			return snapshot.Session{}, fmt.Errorf("window %d: expected a single name: config entry", wi)
+		}
+		w := snapshot.Window{Index: wi, Name: name, Layout: defaultLayout}
+		dir := root
+		panes := []any{value}
+		if wm, ok := asMap(value); ok {
+			dir = resolveDir(asString(wm["root"]), root, home)
+			if l := asString(wm["layout"]); l != "" {
+				w.Layout = l
+			}
+			panes, _ = wm["panes"].([]any)
+			if len(panes) == 0 {
+				panes = []any{nil}
+			}
+		}
+		for pi, rp := range panes {
+			// Named panes are {name: commands}.
+			if _, v, ok := singleEntry(rp); ok {
+				rp = v
+			}
+			w.Panes = append(w.Panes, snapshot.Pane{Index: pi, Path: dir, StartCommand: paneCommand(before, asCommands(rp))})
+		}
+		if startupWindow == name || startupWindow == strconv.Itoa(wi) {
+			s.ActiveWindow = wi
+			if startupPane < len(w.Panes) {
+				w.ActivePane = startupPane
+			}
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	return s, nil
+}
+
+// first returns the value of the first key present in m; tmuxinator keeps
+// accepting the older spelling of several settings.
+func first(m map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"tforge/internal/snapshot"
+)
+
+// ParseTmuxp reads a tmuxp workspace file found in dir. As in tmuxp, a
+// relative session start_directory is resolved against dir, and window and
+// pane ones against the directory above them; ~ expands to home, which is
+// also where a workspace without a start_directory starts. Commands from
+// shell_command_before run ahead of each pane's own commands.
+func ParseTmuxp(b []byte, dir, home string) (snapshot.Session, error) {
+	var raw any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return snapshot.Session{}, err
+	}
+	doc, ok := asMap(raw)
+	if !ok {
+		return snapshot.Session{}, fmt.Errorf("not a tmuxp workspace: expected a mapping")
+	}
+	s := snapshot.Session{Name: asString(doc["session_name"])}
+	if s.Name == "" {
+		return snapshot.Session{}, fmt.Errorf("not a tmuxp workspace: missing session_name")
+	}
+	root := home
+	if d := asString(doc["start_directory"]); d != "" {
+		root = resolveDir(d, dir, home)
+	}
+	before := asCommands(doc["shell_command_before"])
+
+	windows, _ := doc["windows"].([]any)
+	if len(windows) == 0 {
+		return snapshot.Session{}, fmt.Errorf("tmuxp workspace %s has no windows", s.Name)
+	}
+	for wi, rw := range windows {
+		wm, ok := asMap(rw)
+		if !ok {
+			return snapshot.Session{}, fmt.Errorf("window %d: expected a mapping", wi)
+		}
+		w := snapshot.Window{Index: wi, Name: asString(wm["window_name"]), Layout: asString(wm["layout"])}
+		if w.Layout == "" {
+			w.Layout = defaultLayout
+		}
+		if wm["focus"] == true {
+			s.ActiveWindow = wi
+		}
+		winDir := resolveDir(asString(wm["start_directory"]), root, home)
+		winBefore := append(append([]string(nil), before...), asCommands(wm["shell_command_before"])...)
+
+		panes, _ := wm["panes"].([]any)
+		if len(panes) == 0 {
+			panes = []any{nil}
+		}
+		for pi, rp := range panes {
+			p := snapshot.Pane{Index: pi, Path: winDir}
+			var cmds []string
+			if pm, ok := asMap(rp); ok {
+				p.Path = resolveDir(asString(pm["start_directory"]), winDir, home)
+				cmds = asCommands(pm["shell_command"])
+				if pm["focus"] == true {
+					w.ActivePane = pi
+				}
+			} else if c := asString(rp); c != "blank" && c != "pane" {
+				cmds = asCommands(rp)
+			}
+			p.StartCommand = paneCommand(winBefore, cmds)
+			w.Panes = append(w.Panes, p)
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	return s, nil
+}
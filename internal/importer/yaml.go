@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// tmuxp and tmuxinator files are loosely typed: most values may be a string,
+// a list or a mapping. These helpers normalise what yaml.v3 decodes into
+// interface values.
+
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[fmt.Sprint(k)] = v
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func asString(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+// asCommands flattens a command, a list of commands, or a list of tmuxp
+// {cmd: ...} mappings.
+func asCommands(v any) []string {
+	switch c := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		var out []string
+		for _, item := range c {
+			out = append(out, asCommands(item)...)
+		}
+		return out
+	default:
+		if m, ok := asMap(c); ok {
+			return asCommands(m["cmd"])
+		}
+		if s := strings.TrimSpace(asString(c)); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+}
+
+// singleEntry returns the key and value of a one-entry mapping, the shape
+// tmuxinator uses for named windows and panes.
+func singleEntry(v any) (string, any, bool) {
+	m, ok := asMap(v)
+	if !ok || len(m) != 1 {
+		return "", nil, false
+	}
+	for k, v := range m {
+		return k, v, true
+	}
+	return "", nil, false
+}
+
+// resolveDir expands ~ and makes dir relative to base, as both tools do.
+func resolveDir(dir, base, home string) string {
+	switch {
+	case dir == "":
+		return base
+	case dir == "~":
+		return home
+	case strings.HasPrefix(dir, "~/"):
+		return filepath.Join(home, dir[2:])
+	case filepath.IsAbs(dir):
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(base, dir)
+}
+
+// defaultLayout is used for windows whose config leaves the layout to tmux.
+const defaultLayout = "tiled"
+
+// paneCommand joins the commands typed into a pane into one line, which is
+// how snapshot panes record the command to replay.
+func paneCommand(before, cmds []string) string {
+	return strings.Join(append(append([]string(nil), before...), cmds...), "; ")
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tforge/internal/export"
+	"tforge/internal/snapshot"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseTmuxp(t *testing.T) {
+	s, err := ParseTmuxp(readFixture(t, "workspace.tmuxp.yaml"), "/home/me/.tmuxp", "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := snapshot.Session{
+		Name:         "api",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "main-vertical", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src/api", StartCommand: "source .venv/bin/activate; vim ."},
+				{Index: 1, Path: "/home/me/src/api", StartCommand: "source .venv/bin/activate"},
+			}},
+			{Index: 1, Name: "servers", Layout: "tiled", ActivePane: 1, Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src/api/services", StartCommand: "source .venv/bin/activate; make run"},
+				{Index: 1, Path: "/var/log", StartCommand: "source .venv/bin/activate; tail -f syslog"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("unexpected session:\n got %+v\nwant %+v", s, want)
+	}
+}
+
+func TestParseTmuxpRelativeRoot(t *testing.T) {
+	b := []byte("session_name: api\nstart_directory: ./api\nwindows:\n  - window_name: web\n    start_directory: web\n    panes:\n      - blank\n")
+	s, err := ParseTmuxp(b, "/home/me/.tmuxp", "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Windows[0].Panes[0].Path; got != "/home/me/.tmuxp/api/web" {
+		t.Fatalf("expected the root to resolve against the workspace file's directory, got %q", got)
+	}
+}
+
+func TestParseTmuxinator(t *testing.T) {
+	s, err := ParseTmuxinator(readFixture(t, "project.tmuxinator.yml"), "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := snapshot.Session{
+		Name:         "blog",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "main-vertical", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src/blog", StartCommand: "nvm use; vim"},
+				{Index: 1, Path: "/home/me/src/blog", StartCommand: "nvm use"},
+			}},
+			{Index: 1, Name: "server", Layout: "tiled", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src/blog", StartCommand: "nvm use; bundle exec jekyll serve"},
+			}},
+			{Index: 2, Name: "logs", Layout: "tiled", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/home/me/src/blog/log", StartCommand: "nvm use; clear; tail -f development.log"},
+				{Index: 1, Path: "/home/me/src/blog/log", StartCommand: "nvm use; htop"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("unexpected session:\n got %+v\nwant %+v", s, want)
+	}
+}
+
+func TestParseTmuxinatorRejectsERB(t *testing.T) {
+	_, err := ParseTmuxinator([]byte("name: x\nroot: <%= ENV['HOME'] %>\nwindows:\n  - a: b\n"), "/home/me")
+	if err == nil || !strings.Contains(err.Error(), "ERB") {
+		t.Fatalf("expected ERB to be rejected, got %v", err)
+	}
+}
+
+func TestTmuxpRoundTrip(t *testing.T) {
+	in := snapshot.Session{
+		Name:         "hive",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "b25f,160x40,0,0{80x40,0,0,0,79x40,81,0,1}", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/src", StartCommand: "vim main.go"},
+				{Index: 1, Path: "/src/my app"},
+			}},
+			{Index: 1, Name: "logs", Layout: "9a3c,160x40,0,0,2", Panes: []snapshot.Pane{
+				{Index: 0, Path: "/var/log", StartCommand: "tail -f 'it'\\''s.log'"},
+			}},
+		},
+	}
+	b, err := export.Tmuxp(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ParseTmuxp(b, "/home/me/.tmuxp", "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", out, in)
+	}
+}
@@ -0,0 +1,125 @@
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Generation is one capture of a saved layout. Each capture adds a new
+// generation instead of overwriting the previous one.
+type Generation struct {
+	ID           int       `json:"id"`
+	Dir          string    `json:"dir,omitempty"`
+	ScriptPath   string    `json:"script_path,omitempty"`
+	SnapshotPath string    `json:"snapshot_path"`
+	Windows      int       `json:"windows"`
+	Panes        int       `json:"panes"`
+	CapturedAt   time.Time `json:"captured_at"`
+}
+
+// History returns the generations of e, oldest first. Entries written before
+// generations existed are reported as a single generation.
+func History(e Entry) []Generation {
+	if len(e.Generations) > 0 {
+		return e.Generations
+	}
+	if e.SnapshotPath == "" {
+		return nil
+	}
+	return []Generation{{
+		ID:           1,
+		SnapshotPath: e.SnapshotPath,
+		Windows:      e.Windows,
+		Panes:        e.Panes,
+		CapturedAt:   e.CapturedAt,
+	}}
+}
+
+// NextGenerationID returns the id the next capture of e should use.
+func NextGenerationID(e Entry) int {
+	next := 1
+	for _, g := range History(e) {
+		if g.ID >= next {
+			next = g.ID + 1
+		}
+	}
+	return next
+}
+
+// FindGeneration resolves at, either a generation id or a point in time, to
+// a generation of e. A time selects the newest generation captured at or
+// before it; a bare date covers that whole day.
+func FindGeneration(e Entry, at string) (Generation, error) {
+	gens := History(e)
+	if len(gens) == 0 {
+		return Generation{}, fmt.Errorf("%q has no recorded generations", e.Name)
+	}
+	at = strings.TrimSpace(at)
+	if id, err := strconv.Atoi(at); err == nil {
+		for _, g := range gens {
+			if g.ID == id {
+				return g, nil
+			}
+		}
+		return Generation{}, fmt.Errorf("%q has no generation %d (available: %s)", e.Name, id, generationIDs(gens))
+	}
+	t, err := parseAt(at)
+	if err != nil {
+		return Generation{}, err
+	}
+	var found *Generation
+	for i := range gens {
+		if !gens[i].CapturedAt.After(t) && (found == nil || gens[i].CapturedAt.After(found.CapturedAt)) {
+			found = &gens[i]
+		}
+	}
+	if found == nil {
+		return Generation{}, fmt.Errorf("%q has no generation captured at or before %s", e.Name, t.Format(time.RFC3339))
+	}
+	return *found, nil
+}
+
+func parseAt(at string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, at, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", at, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --at value %q: want a generation number, a date (2006-01-02) or a time (RFC 3339)", at)
+}
+
+func generationIDs(gens []Generation) string {
+	ids := make([]string, 0, len(gens))
+	for _, g := range gens {
+		ids = append(ids, strconv.Itoa(g.ID))
+	}
+	return strings.Join(ids, ", ")
+}
+
+// Prune applies a retention policy to gens. The newest generation is always
+// kept; beyond it at most keep generations survive (0 means unlimited), and
+// generations older than maxAge are dropped (0 means no age limit).
+func Prune(gens []Generation, keep int, maxAge time.Duration, now time.Time) (kept, pruned []Generation) {
+	sorted := append([]Generation(nil), gens...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID > sorted[j].ID })
+	for i, g := range sorted {
+		tooMany := keep > 0 && i >= keep
+		tooOld := maxAge > 0 && now.Sub(g.CapturedAt) > maxAge
+		if i > 0 && (tooMany || tooOld) {
+			pruned = append(pruned, g)
+			continue
+		}
+		kept = append(kept, g)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].ID < kept[j].ID })
+	return kept, pruned
+}
@@ -0,0 +1,68 @@
+package journal
+
+import (
+	"testing"
+	"time"
+)
+
+func gensAt(base time.Time, n int) []Generation {
+	var gens []Generation
+	for i := 1; i <= n; i++ {
+		gens = append(gens, Generation{ID: i, SnapshotPath: "/tmp/x", CapturedAt: base.Add(time.Duration(i) * time.Hour)})
+	}
+	return gens
+}
+
+func TestFindGeneration(t *testing.T) {
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	e := Entry{Session: "hive", Generations: gensAt(base, 3)}
+
+	g, err := FindGeneration(e, "2")
+	if err != nil || g.ID != 2 {
+		t.Fatalf("expected generation 2, got %+v (%v)", g, err)
+	}
+	g, err = FindGeneration(e, "2024-05-01T02:30:00Z")
+	if err != nil || g.ID != 2 {
+		t.Fatalf("expected newest generation at or before time, got %+v (%v)", g, err)
+	}
+	if _, err := FindGeneration(e, "2024-04-30T23:00:00Z"); err == nil {
+		t.Fatal("expected error for a time before the first capture")
+	}
+	if _, err := FindGeneration(e, "7"); err == nil {
+		t.Fatal("expected error for an unknown generation")
+	}
+	if _, err := FindGeneration(e, "yesterday"); err == nil {
+		t.Fatal("expected error for an unparseable value")
+	}
+}
+
+func TestHistoryOfLegacyEntry(t *testing.T) {
+	e := Entry{Session: "hive", SnapshotPath: "/tmp/hive.json", Windows: 2}
+	gens := History(e)
+	if len(gens) != 1 || gens[0].ID != 1 || gens[0].SnapshotPath != "/tmp/hive.json" {
+		t.Fatalf("unexpected legacy history: %+v", gens)
+	}
+	if NextGenerationID(e) != 2 {
+		t.Fatalf("expected next id 2, got %d", NextGenerationID(e))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := base.Add(10 * time.Hour)
+
+	kept, pruned := Prune(gensAt(base, 5), 3, 0, now)
+	if len(kept) != 3 || kept[0].ID != 3 || kept[2].ID != 5 || len(pruned) != 2 {
+		t.Fatalf("unexpected count pruning: kept=%+v pruned=%+v", kept, pruned)
+	}
+
+	kept, pruned = Prune(gensAt(base, 5), 0, 7*time.Hour, now)
+	if len(kept) != 3 || kept[0].ID != 3 || len(pruned) != 2 {
+		t.Fatalf("unexpected age pruning: kept=%+v pruned=%+v", kept, pruned)
+	}
+
+	kept, _ = Prune(gensAt(base, 2), 1, time.Minute, now)
+	if len(kept) != 1 || kept[0].ID != 2 {
+		t.Fatalf("expected newest generation to survive, got %+v", kept)
+	}
+}
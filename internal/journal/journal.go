@@ -2,21 +2,35 @@ package journal
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
+
+	"tforge/internal/fsutil"
+	"tforge/internal/names"
 )
 
+// Entry is one saved layout. Name is its identity; Session records the tmux
+// session it was captured from, which several saved layouts may share.
 type Entry struct {
-	Session    string    `json:"session"`
-	ScriptPath string    `json:"script_path"`
-	Windows    int       `json:"windows"`
-	Panes      int       `json:"panes"`
-	CapturedAt time.Time `json:"captured_at"`
+	Name         string       `json:"name"`
+	Session      string       `json:"session"`
+	ScriptPath   string       `json:"script_path"`
+	SnapshotPath string       `json:"snapshot_path,omitempty"`
+	Windows      int          `json:"windows"`
+	Panes        int          `json:"panes"`
+	CapturedAt   time.Time    `json:"captured_at"`
+	Generations  []Generation `json:"generations,omitempty"`
+	// LegacyName is the name the layout's script, sessions/ directory and
+	// ~/.tmux.conf keybinding were saved under before a journal migration
+	// renamed the entry.
+	LegacyName string `json:"legacy_name,omitempty"`
 }
 
 type Data struct {
+	Version int     `json:"version"`
 	Entries []Entry `json:"entries"`
 }
 
@@ -24,11 +38,14 @@ func Path(home string) string {
 	return filepath.Join(home, ".tforge", "journal.json")
 }
 
+// Load reads the journal at path. Journals written by older versions of
+// tforge are upgraded in memory only; Save writes the upgrade back. A missing
+// journal is empty.
 func Load(path string) (Data, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return Data{}, nil
+			return Data{Version: SchemaVersion}, nil
 		}
 		return Data{}, err
 	}
@@ -36,24 +53,39 @@ func Load(path string) (Data, error) {
 	if err := json.Unmarshal(b, &d); err != nil {
 		return Data{}, err
 	}
+	if d.Version > SchemaVersion {
+		return Data{}, fmt.Errorf("journal %s has schema version %d; this tforge supports up to %d, please upgrade", path, d.Version, SchemaVersion)
+	}
+	migrate(&d)
 	return d, nil
 }
 
+// Save writes d to path. The caller must hold the state lock. Replacing a
+// journal written by an older version keeps the original as a backup first.
 func Save(path string, d Data) error {
+	for _, e := range d.Entries {
+		if err := names.Validate(e.Name); err != nil {
+			return fmt.Errorf("journal entry: %w", err)
+		}
+	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
+	if err := backupOutdated(path); err != nil {
+		return fmt.Errorf("back up journal before migrating: %w", err)
+	}
+	d.Version = SchemaVersion
 	b, err := json.MarshalIndent(d, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, append(b, '\n'), 0o644)
+	return fsutil.WriteFileAtomic(path, append(b, '\n'), 0o644)
 }
 
 func Upsert(d Data, e Entry) Data {
 	found := false
 	for i := range d.Entries {
-		if d.Entries[i].Session == e.Session {
+		if d.Entries[i].Name == e.Name {
 			d.Entries[i] = e
 			found = true
 			break
@@ -62,6 +94,50 @@ func Upsert(d Data, e Entry) Data {
 	if !found {
 		d.Entries = append(d.Entries, e)
 	}
-	sort.Slice(d.Entries, func(i, j int) bool { return d.Entries[i].Session < d.Entries[j].Session })
+	sort.Slice(d.Entries, func(i, j int) bool { return d.Entries[i].Name < d.Entries[j].Name })
 	return d
 }
+
+// Find returns the entry saved as name, or nil.
+func Find(d Data, name string) *Entry {
+	for i := range d.Entries {
+		if d.Entries[i].Name == name {
+			return &d.Entries[i]
+		}
+	}
+	return nil
+}
+
+// FileNames returns the names e's files under sessions/ and its ~/.tmux.conf
+// keybinding may be saved under: its own and, when a migration renamed it,
+// its legacy name.
+func FileNames(e Entry) []string {
+	if e.LegacyName == "" || e.LegacyName == e.Name {
+		return []string{e.Name}
+	}
+	return []string{e.Name, e.LegacyName}
+}
+
+// FindFiles returns the entry whose files are saved under name, or nil. See
+// FileNames.
+func FindFiles(d Data, name string) *Entry {
+	for i := range d.Entries {
+		for _, n := range FileNames(d.Entries[i]) {
+			if n == name {
+				return &d.Entries[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Remove drops the entry saved as name and reports whether one was found.
+func Remove(d Data, name string) (Data, bool) {
+	for i := range d.Entries {
+		if d.Entries[i].Name == name {
+			d.Entries = append(d.Entries[:i:i], d.Entries[i+1:]...)
+			return d, true
+		}
+	}
+	return d, false
+}
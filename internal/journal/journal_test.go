@@ -1,6 +1,7 @@
 package journal
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -8,21 +9,48 @@ import (
 
 func TestUpsert(t *testing.T) {
 	d := Data{}
-	d = Upsert(d, Entry{Session: "b", ScriptPath: "/tmp/b.sh", CapturedAt: time.Now()})
-	d = Upsert(d, Entry{Session: "a", ScriptPath: "/tmp/a.sh", CapturedAt: time.Now()})
-	d = Upsert(d, Entry{Session: "a", ScriptPath: "/tmp/new-a.sh", CapturedAt: time.Now()})
+	d = Upsert(d, Entry{Name: "b", Session: "b", ScriptPath: "/tmp/b.sh", CapturedAt: time.Now()})
+	d = Upsert(d, Entry{Name: "a", Session: "a", ScriptPath: "/tmp/a.sh", CapturedAt: time.Now()})
+	d = Upsert(d, Entry{Name: "a", Session: "a", ScriptPath: "/tmp/new-a.sh", CapturedAt: time.Now()})
 	if len(d.Entries) != 2 {
 		t.Fatalf("expected 2 entries, got %d", len(d.Entries))
 	}
-	if d.Entries[0].Session != "a" || d.Entries[0].ScriptPath != "/tmp/new-a.sh" {
+	if d.Entries[0].Name != "a" || d.Entries[0].ScriptPath != "/tmp/new-a.sh" {
 		t.Fatalf("unexpected first entry: %+v", d.Entries[0])
 	}
 }
 
+func TestUpsertKeepsLayoutsOfSameSession(t *testing.T) {
+	d := Data{}
+	d = Upsert(d, Entry{Name: "hive-min", Session: "hive", ScriptPath: "/tmp/hive-min.sh"})
+	d = Upsert(d, Entry{Name: "hive-full", Session: "hive", ScriptPath: "/tmp/hive-full.sh"})
+	if len(d.Entries) != 2 {
+		t.Fatalf("expected one entry per saved name, got %+v", d.Entries)
+	}
+	if e := Find(d, "hive-min"); e == nil || e.ScriptPath != "/tmp/hive-min.sh" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+}
+
+func TestLoadMigratesSessionKeyedEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	legacy := `{"entries": [{"session": "hive", "script_path": "/home/me/.tforge/sessions/hive-full.sh", "windows": 2}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Entries) != 1 || d.Entries[0].Name != "hive-full" || d.Entries[0].Session != "hive" {
+		t.Fatalf("unexpected migrated entry: %+v", d.Entries)
+	}
+}
+
 func TestLoadSave(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "journal.json")
-	in := Data{Entries: []Entry{{Session: "hive", ScriptPath: "/tmp/hive.sh", Windows: 2, Panes: 3, CapturedAt: time.Now().UTC()}}}
+	in := Data{Entries: []Entry{{Name: "hive", Session: "hive", ScriptPath: "/tmp/hive.sh", Windows: 2, Panes: 3, CapturedAt: time.Now().UTC()}}}
 	if err := Save(path, in); err != nil {
 		t.Fatal(err)
 	}
@@ -34,3 +62,25 @@ func TestLoadSave(t *testing.T) {
 		t.Fatalf("unexpected output: %+v", out)
 	}
 }
+
+func TestRemove(t *testing.T) {
+	d := Data{Entries: []Entry{{Name: "a"}, {Name: "b"}}}
+	d, ok := Remove(d, "a")
+	if !ok || len(d.Entries) != 1 || d.Entries[0].Name != "b" {
+		t.Fatalf("unexpected result: ok=%v %+v", ok, d)
+	}
+	if _, ok := Remove(d, "missing"); ok {
+		t.Fatal("expected missing entry to report false")
+	}
+}
+
+func TestSaveRejectsInvalidNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	err := Save(path, Data{Entries: []Entry{{Name: "../../bin/ls", ScriptPath: "/tmp/x.sh"}}})
+	if err == nil {
+		t.Fatal("expected invalid name to be rejected")
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Fatal("expected nothing to be written")
+	}
+}
@@ -0,0 +1,109 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tforge/internal/fsutil"
+	"tforge/internal/names"
+)
+
+// SchemaVersion is the journal format written by Save. Journals without a
+// version field predate versioning and are treated as version 0.
+const SchemaVersion = 1
+
+// migrations[v] upgrades a journal from version v to v+1. To change the
+// format, bump SchemaVersion and append a step; never edit an existing one.
+var migrations = []func(*Data){
+	migrateNames,
+}
+
+func migrate(d *Data) {
+	for d.Version < SchemaVersion {
+		migrations[d.Version](d)
+		d.Version++
+	}
+}
+
+// backupOutdated keeps the original bytes of the journal at path next to it
+// when it was written by an older version and is about to be replaced. An
+// existing backup of the same version is left alone so the first, untouched
+// original survives.
+func backupOutdated(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var d Data
+	if err := json.Unmarshal(b, &d); err != nil || d.Version >= SchemaVersion {
+		return nil
+	}
+	dst := fmt.Sprintf("%s.v%d.bak", path, d.Version)
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(dst, b, info.Mode().Perm())
+}
+
+// migrateNames fills Name for entries written when the journal was keyed by
+// tmux session. The script file was always named after the saved layout, so
+// it is the best record of the name the user chose. Names that predate name
+// validation are replaced by their slug, with a -2 (-3, ...) suffix when that
+// slug is already taken; entries whose names were valid keep them. A renamed
+// entry records the name its files were saved under as its LegacyName.
+func migrateNames(d *Data) {
+	taken := map[string]bool{}
+	for _, e := range d.Entries {
+		if names.Validate(e.Name) == nil {
+			taken[e.Name] = true
+		}
+	}
+	legacies := make([]string, len(d.Entries))
+	for i := range d.Entries {
+		e := &d.Entries[i]
+		if names.Validate(e.Name) == nil {
+			continue
+		}
+		if e.Name == "" {
+			e.Name = strings.TrimSuffix(filepath.Base(e.ScriptPath), ".sh")
+			if e.ScriptPath == "" {
+				e.Name = e.Session
+			}
+		}
+		legacy := e.Name
+		if e.ScriptPath != "" {
+			legacy = strings.TrimSuffix(filepath.Base(e.ScriptPath), ".sh")
+		}
+		if names.Validate(e.Name) != nil {
+			e.Name = names.Slug(e.Name)
+		}
+		base := e.Name
+		for n := 2; taken[e.Name]; n++ {
+			suffix := fmt.Sprintf("-%d", n)
+			e.Name = base[:min(len(base), names.MaxLength-len(suffix))] + suffix
+		}
+		taken[e.Name] = true
+		if legacy != e.Name {
+			legacies[i] = legacy
+		}
+	}
+	// Old journals could point several sessions at the same script, and a
+	// legacy file can only belong to one of them: the first entry to claim it,
+	// unless another entry now owns that name outright.
+	for i, legacy := range legacies {
+		if legacy != "" && !taken[legacy] {
+			d.Entries[i].LegacyName = legacy
+			taken[legacy] = true
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tforge/internal/names"
+)
+
+func TestLoadMigratesAndSaveBacksUpUnversionedJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	legacy := `{"entries": [{"session": "my project", "script_path": "", "windows": 1}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Version != SchemaVersion || d.Entries[0].Name != "my-project" {
+		t.Fatalf("unexpected migrated journal: %+v", d)
+	}
+	// Reading alone must not touch the disk; only writers hold the lock.
+	if _, err := os.Stat(path + ".v0.bak"); !os.IsNotExist(err) {
+		t.Fatalf("expected Load not to write a backup, got %v", err)
+	}
+
+	if err := Save(path, d); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != legacy {
+		t.Fatalf("backup does not match original: %q", b)
+	}
+	b, _ = os.ReadFile(path)
+	if !strings.Contains(string(b), `"version": 1`) {
+		t.Fatalf("expected saved journal to record its version:\n%s", b)
+	}
+
+	// Later saves replace a current journal and leave the backup alone.
+	if err := Save(path, Data{}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path + ".v0.bak"); string(b) != legacy {
+		t.Fatalf("backup was overwritten: %q", b)
+	}
+}
+
+func TestMigrateNamesDeduplicatesSlugs(t *testing.T) {
+	d := Data{Entries: []Entry{
+		{Session: "my app"},
+		{Name: "my-app", Session: "my-app"},
+		{Session: "my/app"},
+		{Name: strings.Repeat("x", names.MaxLength) + "!"},
+		{Name: strings.Repeat("x", names.MaxLength) + "?"},
+	}}
+	migrateNames(&d)
+	var got []string
+	for _, e := range d.Entries {
+		got = append(got, e.Name)
+	}
+	long := strings.Repeat("x", names.MaxLength)
+	want := []string{"my-app-2", "my-app", "my-app-3", long, long[:names.MaxLength-2] + "-2"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("got names %q, want %q", got, want)
+	}
+}
+
+func TestMigrateNamesKeepsLegacyFileName(t *testing.T) {
+	d := Data{Entries: []Entry{
+		{Name: "my app", Session: "my app", ScriptPath: "/home/me/.tforge/sessions/my app.sh"},
+		{Session: "hive", ScriptPath: "/home/me/.tforge/sessions/hive.sh"},
+	}}
+	migrateNames(&d)
+	if e := d.Entries[0]; e.Name != "my-app" || e.LegacyName != "my app" {
+		t.Fatalf("expected my app to become my-app with its old name kept, got %+v", e)
+	}
+	if e := d.Entries[1]; e.Name != "hive" || e.LegacyName != "" {
+		t.Fatalf("expected hive to keep its name without a legacy one, got %+v", e)
+	}
+	if FindFiles(d, "my app") != &d.Entries[0] || FindFiles(d, "my-app") != &d.Entries[0] {
+		t.Fatal("expected both names to find the renamed entry")
+	}
+}
+
+func TestMigrateNamesGivesEachLegacyNameOneOwner(t *testing.T) {
+	d := Data{Entries: []Entry{
+		{Session: "my app", ScriptPath: "/home/me/.tforge/sessions/my app.sh"},
+		{Session: "my app 2", ScriptPath: "/home/me/.tforge/sessions/my app.sh"},
+		{Session: "old", ScriptPath: "/home/me/.tforge/sessions/ops.sh"},
+		{Name: "ops", Session: "ops", ScriptPath: "/home/me/.tforge/sessions/ops.sh"},
+	}}
+	migrateNames(&d)
+	if e := d.Entries[0]; e.Name != "my-app" || e.LegacyName != "my app" {
+		t.Fatalf("expected the first entry to keep the old file name, got %+v", e)
+	}
+	if e := d.Entries[1]; e.Name != "my-app-2" || e.LegacyName != "" {
+		t.Fatalf("expected the second entry to get no legacy name, got %+v", e)
+	}
+	if e := d.Entries[2]; e.Name != "ops-2" || e.LegacyName != "" {
+		t.Fatalf("expected no legacy name that another entry owns, got %+v", e)
+	}
+	if FindFiles(d, "my app") != &d.Entries[0] || FindFiles(d, "ops") != &d.Entries[3] {
+		t.Fatal("expected each legacy name to find a single entry")
+	}
+}
+
+func TestLoadRefusesNewerJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "entries": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "please upgrade") {
+		t.Fatalf("expected upgrade hint, got %v", err)
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("have %d migrations for schema version %d", len(migrations), SchemaVersion)
+	}
+}
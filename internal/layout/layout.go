@@ -0,0 +1,220 @@
+// Package layout parses and writes tmux window layout strings, as reported by
+// #{window_layout} and accepted by select-layout:
+//
+//	306f,160x40,0,0{80x40,0,0,0,79x40,81,0[79x20,81,0,1,79x19,81,21,2]}
+//
+// The leading field is a checksum of the rest. Each cell is WxH,X,Y followed
+// by a pane id, or by its children in {} (side by side) or [] (stacked).
+package layout
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	// Pane is a leaf cell holding one pane.
+	Pane Kind = iota
+	// LeftRight cells place their children side by side ({}).
+	LeftRight
+	// TopBottom cells stack their children ([]).
+	TopBottom
+)
+
+// Cell is a node of a layout tree. Sizes and offsets are in terminal cells;
+// siblings are separated by a one-cell border. PaneID is the number of the
+// tmux pane (%N) shown in a leaf, or -1 if the layout did not name one.
+type Cell struct {
+	Kind     Kind
+	Width    int
+	Height   int
+	X        int
+	Y        int
+	PaneID   int
+	Children []*Cell
+}
+
+// Parse reads a layout string and verifies its checksum.
+func Parse(s string) (*Cell, error) {
+	sum, body, ok := strings.Cut(s, ",")
+	if !ok || len(sum) != 4 {
+		return nil, fmt.Errorf("invalid layout %q: missing checksum", s)
+	}
+	want, err := strconv.ParseUint(sum, 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %q: bad checksum %q", s, sum)
+	}
+	if got := Checksum(body); uint16(want) != got {
+		return nil, fmt.Errorf("invalid layout %q: checksum %04x does not match %04x", s, want, got)
+	}
+	p := parser{s: body}
+	c, err := p.cell()
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %q: %w", s, err)
+	}
+	if p.i != len(body) {
+		return nil, fmt.Errorf("invalid layout %q: unexpected %q at offset %d", s, body[p.i:], p.i)
+	}
+	return c, nil
+}
+
+// String serialises c with a freshly computed checksum.
+func (c *Cell) String() string {
+	var b strings.Builder
+	c.write(&b)
+	body := b.String()
+	return fmt.Sprintf("%04x,%s", Checksum(body), body)
+}
+
+func (c *Cell) write(b *strings.Builder) {
+	fmt.Fprintf(b, "%dx%d,%d,%d", c.Width, c.Height, c.X, c.Y)
+	switch c.Kind {
+	case Pane:
+		if c.PaneID >= 0 {
+			fmt.Fprintf(b, ",%d", c.PaneID)
+		}
+	case LeftRight, TopBottom:
+		opener, closer := byte('{'), byte('}')
+		if c.Kind == TopBottom {
+			opener, closer = '[', ']'
+		}
+		b.WriteByte(opener)
+		for i, child := range c.Children {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			child.write(b)
+		}
+		b.WriteByte(closer)
+	}
+}
+
+// Panes returns the leaf cells in layout order, which is the order of the
+// window's pane indexes.
+func (c *Cell) Panes() []*Cell {
+	if c.Kind == Pane {
+		return []*Cell{c}
+	}
+	var out []*Cell
+	for _, child := range c.Children {
+		out = append(out, child.Panes()...)
+	}
+	return out
+}
+
+// Stacked returns a tree of n equally sized panes stacked top to bottom, for
+// windows whose own layout cannot be read. It has no sizes or pane ids.
+func Stacked(n int) *Cell {
+	if n == 1 {
+		return &Cell{Kind: Pane, PaneID: -1}
+	}
+	c := &Cell{Kind: TopBottom, PaneID: -1}
+	for i := 0; i < n; i++ {
+		c.Children = append(c.Children, &Cell{Kind: Pane, Width: 1, Height: 1, PaneID: -1})
+	}
+	return c
+}
+
+// Checksum computes the checksum tmux puts in front of a layout body.
+func Checksum(body string) uint16 {
+	var csum uint16
+	for i := 0; i < len(body); i++ {
+		csum = (csum >> 1) + ((csum & 1) << 15)
+		csum += uint16(body[i])
+	}
+	return csum
+}
+
+type parser struct {
+	s string
+	i int
+}
+
+func (p *parser) cell() (*Cell, error) {
+	c := &Cell{PaneID: -1}
+	var err error
+	if c.Width, err = p.number('x'); err != nil {
+		return nil, err
+	}
+	if c.Height, err = p.number(','); err != nil {
+		return nil, err
+	}
+	if c.X, err = p.number(','); err != nil {
+		return nil, err
+	}
+	if c.Y, err = p.number(0); err != nil {
+		return nil, err
+	}
+	if p.i == len(p.s) {
+		return c, nil
+	}
+	switch p.s[p.i] {
+	case ',':
+		// A pane id, unless the comma starts the next sibling (WxH).
+		j := p.i + 1
+		for j < len(p.s) && p.s[j] >= '0' && p.s[j] <= '9' {
+			j++
+		}
+		if j > p.i+1 && (j == len(p.s) || p.s[j] != 'x') {
+			c.PaneID, _ = strconv.Atoi(p.s[p.i+1 : j])
+			p.i = j
+		}
+		return c, nil
+	case '{', '[':
+		c.Kind = LeftRight
+		closer := byte('}')
+		if p.s[p.i] == '[' {
+			c.Kind, closer = TopBottom, ']'
+		}
+		p.i++
+		for {
+			child, err := p.cell()
+			if err != nil {
+				return nil, err
+			}
+			c.Children = append(c.Children, child)
+			if p.i >= len(p.s) {
+				return nil, fmt.Errorf("unterminated split")
+			}
+			if p.s[p.i] == closer {
+				p.i++
+				break
+			}
+			if p.s[p.i] != ',' {
+				return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.i], p.i)
+			}
+			p.i++
+		}
+		if len(c.Children) < 2 {
+			return nil, fmt.Errorf("split with fewer than two cells")
+		}
+		return c, nil
+	}
+	return c, nil
+}
+
+// number reads a decimal number followed by sep, consuming sep. A zero sep
+// means the number may be followed by anything.
+func (p *parser) number(sep byte) (int, error) {
+	start := p.i
+	for p.i < len(p.s) && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+		p.i++
+	}
+	if p.i == start {
+		return 0, fmt.Errorf("expected a number at offset %d", start)
+	}
+	n, err := strconv.Atoi(p.s[start:p.i])
+	if err != nil {
+		return 0, err
+	}
+	if sep != 0 {
+		if p.i >= len(p.s) || p.s[p.i] != sep {
+			return 0, fmt.Errorf("expected %q at offset %d", sep, p.i)
+		}
+		p.i++
+	}
+	return n, nil
+}
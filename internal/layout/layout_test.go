@@ -0,0 +1,95 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+)
+
+// Layouts reported by tmux 3.3a.
+var real = []string{
+	"b25f,80x24,0,0,2",
+	"306f,160x40,0,0{80x40,0,0,0,79x40,81,0[79x20,81,0,1,79x19,81,21,2]}",
+	"6331,160x40,0,0[160x20,0,0{80x20,0,0,3,79x20,81,0,5},160x19,0,21,4]",
+}
+
+func TestParseRoundTrips(t *testing.T) {
+	for _, s := range real {
+		c, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.String(); got != s {
+			t.Fatalf("String() = %q, want %q", got, s)
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	c, err := Parse(real[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Kind != TopBottom || len(c.Children) != 2 || c.Children[0].Kind != LeftRight {
+		t.Fatalf("unexpected tree: %+v", c)
+	}
+	var ids []int
+	for _, p := range c.Panes() {
+		ids = append(ids, p.PaneID)
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 5 || ids[2] != 4 {
+		t.Fatalf("unexpected pane order %v", ids)
+	}
+	if p := c.Panes()[2]; p.Width != 160 || p.Height != 19 || p.X != 0 || p.Y != 21 {
+		t.Fatalf("unexpected geometry %+v", p)
+	}
+}
+
+func TestParseRejectsBadInput(t *testing.T) {
+	for _, s := range []string{
+		"tiled",
+		"ffff,80x24,0,0,2",
+		"b25f,80x24,0,0,2}",
+		"0000,80x24",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+}
+
+func TestStringRecomputesChecksum(t *testing.T) {
+	c, err := Parse(real[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Children[0].Width, c.Children[1].Width, c.Children[1].X = 60, 99, 61
+	for _, p := range c.Children[1].Children {
+		p.Width, p.X = 99, 61
+	}
+	s := c.String()
+	if strings.HasPrefix(s, "306f,") {
+		t.Fatal("expected a new checksum")
+	}
+	if _, err := Parse(s); err != nil {
+		t.Fatalf("re-serialised layout does not parse: %v", err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range real {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		c, err := Parse(s)
+		if err != nil {
+			return
+		}
+		again, err := Parse(c.String())
+		if err != nil {
+			t.Fatalf("String() of %q does not parse: %v", s, err)
+		}
+		if again.String() != c.String() {
+			t.Fatalf("unstable serialisation of %q", s)
+		}
+	})
+}
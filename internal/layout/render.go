@@ -0,0 +1,63 @@
+package layout
+
+// Render draws c as an ASCII box diagram of width x height characters, one
+// box per pane, and writes labels[i] into the i-th pane (in Panes order),
+// cutting lines that do not fit. It fails if a box would be too small to
+// show at all.
+func Render(c *Cell, width, height int, labels [][]string) ([]string, error) {
+	scaled, err := Scale(c, width-2, height-2)
+	if err != nil {
+		return nil, err
+	}
+	grid := make([][]rune, height)
+	wall := make([][]bool, height)
+	for y := range grid {
+		grid[y] = make([]rune, width)
+		wall[y] = make([]bool, width)
+		for x := range grid[y] {
+			grid[y][x], wall[y][x] = ' ', true
+		}
+	}
+	for i, p := range scaled.Panes() {
+		for y := p.Y + 1; y <= p.Y+p.Height; y++ {
+			for x := p.X + 1; x <= p.X+p.Width; x++ {
+				wall[y][x] = false
+			}
+		}
+		if i >= len(labels) {
+			continue
+		}
+		for j, line := range labels[i] {
+			if j >= p.Height {
+				break
+			}
+			r := []rune(line)
+			if len(r) > p.Width {
+				r = r[:p.Width]
+			}
+			copy(grid[p.Y+1+j][p.X+1:], r)
+		}
+	}
+	for y := range grid {
+		for x := range grid[y] {
+			if !wall[y][x] {
+				continue
+			}
+			vertical := (y > 0 && wall[y-1][x]) || (y < height-1 && wall[y+1][x])
+			horizontal := (x > 0 && wall[y][x-1]) || (x < width-1 && wall[y][x+1])
+			switch {
+			case vertical && horizontal:
+				grid[y][x] = '+'
+			case horizontal:
+				grid[y][x] = '-'
+			default:
+				grid[y][x] = '|'
+			}
+		}
+	}
+	out := make([]string, height)
+	for y, row := range grid {
+		out[y] = string(row)
+	}
+	return out, nil
+}
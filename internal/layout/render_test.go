@@ -0,0 +1,39 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	c, err := Parse("6331,160x40,0,0[160x20,0,0{80x20,0,0,3,79x20,81,0,5},160x19,0,21,4]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Render(c, 20, 7, [][]string{{"editor", "vim"}, {"a very long label"}, {"shell"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"+---------+--------+",
+		"|editor   |a very l|",
+		"|vim      |        |",
+		"+---------+--------+",
+		"|shell             |",
+		"|                  |",
+		"+------------------+",
+	}, "\n")
+	if strings.Join(got, "\n") != want {
+		t.Fatalf("unexpected diagram:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRenderRejectsTooSmall(t *testing.T) {
+	c, err := Parse("306f,160x40,0,0{80x40,0,0,0,79x40,81,0[79x20,81,0,1,79x19,81,21,2]}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Render(c, 4, 10, nil); err == nil {
+		t.Fatal("expected a 4 column diagram to be rejected")
+	}
+}
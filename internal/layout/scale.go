@@ -0,0 +1,133 @@
+package layout
+
+import "fmt"
+
+// Scale returns a copy of c resized to width x height. Every split keeps the
+// proportions of its children; borders between siblings stay one cell wide.
+// It fails if the target is too small to give every pane at least one cell.
+func Scale(c *Cell, width, height int) (*Cell, error) {
+	if width < c.minSize(LeftRight) || height < c.minSize(TopBottom) {
+		return nil, fmt.Errorf("layout needs at least %dx%d cells, have %dx%d", c.minSize(LeftRight), c.minSize(TopBottom), width, height)
+	}
+	out := c.clone()
+	out.resize(0, 0, width, height)
+	return out, nil
+}
+
+func (c *Cell) clone() *Cell {
+	out := *c
+	out.Children = make([]*Cell, len(c.Children))
+	for i, child := range c.Children {
+		out.Children[i] = child.clone()
+	}
+	if len(c.Children) == 0 {
+		out.Children = nil
+	}
+	return &out
+}
+
+// MinSize is the smallest size c can be scaled or rendered to, not counting
+// the frame Render draws around it.
+func (c *Cell) MinSize() (width, height int) {
+	return c.minSize(LeftRight), c.minSize(TopBottom)
+}
+
+// minSize is the fewest cells c can occupy along axis (LeftRight for width,
+// TopBottom for height).
+func (c *Cell) minSize(axis Kind) int {
+	switch {
+	case c.Kind == Pane:
+		return 1
+	case c.Kind == axis:
+		n := len(c.Children) - 1
+		for _, child := range c.Children {
+			n += child.minSize(axis)
+		}
+		return n
+	default:
+		n := 0
+		for _, child := range c.Children {
+			n = max(n, child.minSize(axis))
+		}
+		return n
+	}
+}
+
+func (c *Cell) resize(x, y, width, height int) {
+	c.X, c.Y, c.Width, c.Height = x, y, width, height
+	if c.Kind == Pane {
+		return
+	}
+	old := make([]int, len(c.Children))
+	mins := make([]int, len(c.Children))
+	avail := width
+	if c.Kind == TopBottom {
+		avail = height
+	}
+	avail -= len(c.Children) - 1
+	for i, child := range c.Children {
+		old[i], mins[i] = child.Width, child.minSize(LeftRight)
+		if c.Kind == TopBottom {
+			old[i], mins[i] = child.Height, child.minSize(TopBottom)
+		}
+	}
+	pos := x
+	if c.Kind == TopBottom {
+		pos = y
+	}
+	for i, size := range distribute(old, mins, avail) {
+		if c.Kind == LeftRight {
+			c.Children[i].resize(pos, y, size, height)
+		} else {
+			c.Children[i].resize(x, pos, width, size)
+		}
+		pos += size + 1
+	}
+}
+
+// distribute splits total cells in proportion to weights, giving each share
+// at least its minimum. Rounding leftovers go to the shares that lost the
+// most to rounding. The caller guarantees total >= sum(mins).
+func distribute(weights, mins []int, total int) []int {
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+	if sum == 0 {
+		weights, sum = make([]int, len(weights)), len(weights)
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	sizes := make([]int, len(weights))
+	rem := make([]int, len(weights))
+	used := 0
+	for i, w := range weights {
+		sizes[i], rem[i] = w*total/sum, w*total%sum
+		used += sizes[i]
+	}
+	for ; used < total; used++ {
+		best := 0
+		for i := range rem {
+			if rem[i] > rem[best] {
+				best = i
+			}
+		}
+		sizes[best]++
+		rem[best] = -1
+	}
+	// Raise shares below their minimum at the expense of the largest ones.
+	for i := range sizes {
+		for sizes[i] < mins[i] {
+			big := 0
+			for j := range sizes {
+				if sizes[j]-mins[j] > sizes[big]-mins[big] {
+					big = j
+				}
+			}
+			sizes[big]--
+			sizes[i]++
+		}
+	}
+	return sizes
+}
@@ -0,0 +1,95 @@
+package layout
+
+import "testing"
+
+// checkGeometry verifies that children tile their parent exactly.
+func checkGeometry(t *testing.T, c *Cell) {
+	t.Helper()
+	if c.Kind == Pane {
+		if c.Width < 1 || c.Height < 1 {
+			t.Fatalf("empty pane %+v", c)
+		}
+		return
+	}
+	pos := c.X
+	if c.Kind == TopBottom {
+		pos = c.Y
+	}
+	for _, child := range c.Children {
+		if c.Kind == LeftRight && (child.X != pos || child.Y != c.Y || child.Height != c.Height) {
+			t.Fatalf("misplaced child %+v in %+v", child, c)
+		}
+		if c.Kind == TopBottom && (child.Y != pos || child.X != c.X || child.Width != c.Width) {
+			t.Fatalf("misplaced child %+v in %+v", child, c)
+		}
+		if c.Kind == LeftRight {
+			pos += child.Width + 1
+		} else {
+			pos += child.Height + 1
+		}
+		checkGeometry(t, child)
+	}
+	end := c.X + c.Width + 1
+	if c.Kind == TopBottom {
+		end = c.Y + c.Height + 1
+	}
+	if pos != end {
+		t.Fatalf("children of %+v do not fill it", c)
+	}
+}
+
+func TestScaleKeepsProportions(t *testing.T) {
+	c, err := Parse(real[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaled, err := Scale(c, 80, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGeometry(t, scaled)
+	if scaled.Width != 80 || scaled.Height != 20 {
+		t.Fatalf("unexpected root size %dx%d", scaled.Width, scaled.Height)
+	}
+	if w := scaled.Children[0].Width; w != 40 {
+		t.Fatalf("expected left half to stay half, got %d", w)
+	}
+	if _, err := Parse(scaled.String()); err != nil {
+		t.Fatalf("scaled layout does not parse: %v", err)
+	}
+	if c.Width != 160 {
+		t.Fatal("expected the original tree to be left alone")
+	}
+}
+
+func TestScaleRejectsTooSmall(t *testing.T) {
+	c, err := Parse(real[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scale(c, 2, 40); err == nil {
+		t.Fatal("expected an error for a window too narrow for three panes")
+	}
+	scaled, err := Scale(c, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGeometry(t, scaled)
+}
+
+func FuzzScale(f *testing.F) {
+	for _, s := range real {
+		f.Add(s, 80, 24)
+	}
+	f.Fuzz(func(t *testing.T, s string, width, height int) {
+		c, err := Parse(s)
+		if err != nil || width > 2000 || height > 2000 {
+			return
+		}
+		scaled, err := Scale(c, width, height)
+		if err != nil {
+			return
+		}
+		checkGeometry(t, scaled)
+	})
+}
@@ -0,0 +1,76 @@
+package names
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxLength bounds saved layout names so that derived file names stay well
+// within filesystem limits.
+const MaxLength = 64
+
+// reserved names collide with tforge's own files under ~/.tforge.
+var reserved = map[string]bool{
+	"journal":  true,
+	"config":   true,
+	"sessions": true,
+	"tforge":   true,
+}
+
+// Validate reports whether name can be used as a saved layout name. Names end
+// up in file paths under ~/.tforge/sessions and in ~/.tmux.conf markers, so
+// only ASCII letters, digits, '.', '_' and '-' are allowed, starting with a
+// letter or digit.
+func Validate(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("name must not be empty")
+	case len(name) > MaxLength:
+		return fmt.Errorf("name %q is longer than %d characters", name, MaxLength)
+	case !isAlnum(name[0]):
+		return fmt.Errorf("name %q must start with a letter or digit", name)
+	case reserved[strings.ToLower(name)]:
+		return fmt.Errorf("name %q is reserved", name)
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !isAlnum(c) && c != '.' && c != '_' && c != '-' {
+			return fmt.Errorf("name %q contains %q; use letters, digits, '.', '_' or '-'", name, string(rune(c)))
+		}
+	}
+	return nil
+}
+
+// Slug derives a valid name from s by replacing runs of disallowed characters
+// with '-'.
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAlnum(c) || c == '.' || c == '_' || c == '-' {
+			b.WriteByte(c)
+			dash = false
+			continue
+		}
+		if !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimLeft(b.String(), "-._")
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength]
+	}
+	slug = strings.TrimRight(slug, "-.")
+	if slug == "" {
+		return "layout"
+	}
+	if reserved[strings.ToLower(slug)] {
+		slug += "-layout"
+	}
+	return slug
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,44 @@
+package names
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, ok := range []string{"hive", "hive-min", "api_v2.full", "2024"} {
+		if err := Validate(ok); err != nil {
+			t.Fatalf("expected %q to be valid: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "../../bin/ls", "a/b", ".hidden", "-flag", "two words", "x\n# tforge end: y", "journal", strings.Repeat("a", MaxLength+1)} {
+		if err := Validate(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSlugIsAlwaysValid(t *testing.T) {
+	cases := map[string]string{
+		"my session":   "my-session",
+		"../../bin/ls": "bin-ls",
+		"x\n# tforge":  "x-tforge",
+		"Config":       "Config-layout",
+		"///":          "layout",
+		"api|worker":   "api-worker",
+		"héllo":        "h-llo",
+		"hive":         "hive",
+	}
+	for in, want := range cases {
+		got := Slug(in)
+		if got != want {
+			t.Fatalf("Slug(%q) = %q, want %q", in, got, want)
+		}
+		if err := Validate(got); err != nil {
+			t.Fatalf("Slug(%q) = %q is not valid: %v", in, got, err)
+		}
+	}
+	if got := Slug(strings.Repeat("ab ", 40)); Validate(got) != nil {
+		t.Fatalf("expected long slug to be truncated to a valid name, got %q", got)
+	}
+}
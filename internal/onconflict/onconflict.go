@@ -0,0 +1,56 @@
+// Package onconflict names what restoring a saved layout does when a session
+// with the saved name is already running. Generated scripts, the restore
+// engine and the settings file all share these strategies.
+package onconflict
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategy is what restoring does when a session with the saved name is
+// already running.
+type Strategy string
+
+const (
+	// Attach attaches to the running session, unless it is a fresh session
+	// with a single window and pane, which is replaced.
+	Attach Strategy = "attach"
+	// Replace kills the running session and restores the saved one.
+	Replace Strategy = "replace"
+	// Rename restores the saved session as <name>-2 (or -3, ...).
+	Rename Strategy = "rename"
+	// Merge adds the saved windows the running session has no window of the
+	// same name for.
+	Merge Strategy = "merge"
+	// Fail leaves the running session alone and reports an error.
+	Fail Strategy = "fail"
+)
+
+var strategies = []Strategy{Attach, Replace, Rename, Merge, Fail}
+
+// Parse reads a strategy name; "" means Attach.
+func Parse(s string) (Strategy, error) {
+	if s == "" {
+		return Attach, nil
+	}
+	for _, c := range strategies {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	names := make([]string, len(strategies))
+	for i, c := range strategies {
+		names[i] = string(c)
+	}
+	return "", fmt.Errorf("unknown conflict strategy %q (supported: %s)", s, strings.Join(names, ", "))
+}
+
+func (c *Strategy) UnmarshalText(b []byte) error {
+	v, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
@@ -0,0 +1,267 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"tforge/internal/generate"
+	"tforge/internal/layout"
+	"tforge/internal/onconflict"
+	"tforge/internal/snapshot"
+	"tforge/internal/tmux"
+)
+
+type TmuxDriver interface {
+	SessionExists(ctx context.Context, session string) (bool, error)
+	SessionSize(ctx context.Context, session string) (windows, panes int, err error)
+	NewSession(ctx context.Context, session, window, dir, command string) (windowID, paneID string, err error)
+	NewWindow(ctx context.Context, session, window, dir, command string) (windowID, paneID string, err error)
+	SplitWindow(ctx context.Context, target, dir, command string) (string, error)
+	SelectLayout(ctx context.Context, target, layout string) error
+	SelectPane(ctx context.Context, target string) error
+	SelectWindow(ctx context.Context, target string) error
+	SendKeys(ctx context.Context, target, text string) error
+	KillSession(ctx context.Context, session string) error
+	ListWindows(ctx context.Context, session string) ([]tmux.WindowInfo, error)
+	KillWindow(ctx context.Context, target string) error
+	ClientSize(ctx context.Context) (width, height int, err error)
+}
+
+// StepError identifies the tmux operation that failed while rebuilding a
+// session. Pane is -1 for window-level steps.
+type StepError struct {
+	Step   string
+	Window snapshot.Window
+	Pane   int
+	Err    error
+}
+
+func (e *StepError) Error() string {
+	if e.Pane < 0 {
+		return fmt.Sprintf("window %d (%s): %s: %v", e.Window.Index, e.Window.Name, e.Step, e.Err)
+	}
+	return fmt.Sprintf("window %d (%s) pane %d: %s: %v", e.Window.Index, e.Window.Name, e.Pane, e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+type Result struct {
+	// Session is the session to attach to. It differs from the saved name
+	// when the restore was renamed to avoid a running session.
+	Session string
+	// Created is false when an existing session was kept or merged into.
+	Created bool
+	// Added names the windows a merge added to the running session.
+	Added []string
+}
+
+type Engine struct {
+	tmux TmuxDriver
+	// OnConflict is what Restore does when the session is already running;
+	// the zero value means onconflict.Attach.
+	OnConflict onconflict.Strategy
+}
+
+func NewEngine(tmux TmuxDriver) *Engine {
+	return &Engine{tmux: tmux}
+}
+
+// Restore rebuilds s by driving tmux directly. A running session of the same
+// name is handled as e.OnConflict says. If a step fails, whatever was built is
+// removed again.
+func (e *Engine) Restore(ctx context.Context, s snapshot.Session) (Result, error) {
+	if len(s.Windows) == 0 {
+		return Result{}, fmt.Errorf("session has no windows")
+	}
+	exists, err := e.tmux.SessionExists(ctx, s.Name)
+	if err != nil {
+		return Result{}, err
+	}
+	merge := false
+	if exists {
+		switch e.OnConflict {
+		case onconflict.Attach, "":
+			windows, panes, err := e.tmux.SessionSize(ctx, s.Name)
+			if err != nil {
+				return Result{}, err
+			}
+			if windows != 1 || panes != 1 {
+				return Result{Session: s.Name}, nil
+			}
+			if err := e.tmux.KillSession(ctx, s.Name); err != nil {
+				return Result{}, fmt.Errorf("replace fresh session %q: %w", s.Name, err)
+			}
+		case onconflict.Replace:
+			if err := e.tmux.KillSession(ctx, s.Name); err != nil {
+				return Result{}, fmt.Errorf("replace session %q: %w", s.Name, err)
+			}
+		case onconflict.Rename:
+			if s.Name, err = e.freeName(ctx, s.Name); err != nil {
+				return Result{}, err
+			}
+		case onconflict.Merge:
+			if s.Windows, err = e.missingWindows(ctx, s); err != nil {
+				return Result{}, err
+			}
+			if len(s.Windows) == 0 {
+				return Result{Session: s.Name}, nil
+			}
+			merge = true
+		case onconflict.Fail:
+			return Result{}, fmt.Errorf("tmux session %q already exists", s.Name)
+		default:
+			return Result{}, fmt.Errorf("unknown conflict strategy %q", e.OnConflict)
+		}
+	}
+
+	// Layouts are stored at the size they were captured at; fit them to the
+	// client the session will be shown in, when there is one.
+	width, height, err := e.tmux.ClientSize(ctx)
+	if err != nil {
+		width, height = 0, 0
+	}
+	windowIDs, err := e.build(ctx, s, merge, width, height)
+	if err != nil {
+		return Result{}, e.cleanup(ctx, s.Name, merge, windowIDs, err)
+	}
+	res := Result{Session: s.Name, Created: !merge}
+	if merge {
+		for _, w := range s.Windows {
+			res.Added = append(res.Added, w.Name)
+		}
+	}
+	return res, nil
+}
+
+// cleanup removes what a failed build left behind: the new session, or the
+// windows a merge added so far.
+func (e *Engine) cleanup(ctx context.Context, session string, merge bool, windowIDs []string, err error) error {
+	if len(windowIDs) == 0 {
+		return err
+	}
+	if !merge {
+		if kErr := e.tmux.KillSession(ctx, session); kErr != nil {
+			return fmt.Errorf("%w (cleanup of session %q failed: %v)", err, session, kErr)
+		}
+		return fmt.Errorf("%w (partially restored session %q was removed)", err, session)
+	}
+	for _, id := range windowIDs {
+		if kErr := e.tmux.KillWindow(ctx, id); kErr != nil {
+			return fmt.Errorf("%w (cleanup of window %s in session %q failed: %v)", err, id, session, kErr)
+		}
+	}
+	return fmt.Errorf("%w (windows added to session %q were removed)", err, session)
+}
+
+// freeName returns the first of name-2, name-3, ... with no running session.
+func (e *Engine) freeName(ctx context.Context, name string) (string, error) {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", name, n)
+		exists, err := e.tmux.SessionExists(ctx, candidate)
+		if err != nil || !exists {
+			return candidate, err
+		}
+	}
+}
+
+// missingWindows returns the windows of s the running session has no window
+// of the same name for. Unnamed windows cannot be matched and are always
+// returned.
+func (e *Engine) missingWindows(ctx context.Context, s snapshot.Session) ([]snapshot.Window, error) {
+	running, err := e.tmux.ListWindows(ctx, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, w := range running {
+		have[w.Name] = true
+	}
+	var out []snapshot.Window
+	for _, w := range s.Windows {
+		if w.Name == "" || !have[w.Name] {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// build creates the session window by window, or with merge adds the windows
+// to the running session. It returns the ids of the windows it created, so
+// the caller knows what to clean up.
+func (e *Engine) build(ctx context.Context, s snapshot.Session, merge bool, width, height int) (windowIDs []string, err error) {
+	activeWindowID := ""
+	for i, w := range s.Windows {
+		if len(w.Panes) == 0 {
+			return windowIDs, &StepError{Step: "validate", Window: w, Pane: -1, Err: fmt.Errorf("window has no panes")}
+		}
+		first := w.Panes[0]
+		var windowID, paneID string
+		if i == 0 && !merge {
+			windowID, paneID, err = e.tmux.NewSession(ctx, s.Name, w.Name, filepath.Clean(first.Path), generate.ContentsCommand(first))
+			if err != nil {
+				return windowIDs, &StepError{Step: "new-session", Window: w, Pane: first.Index, Err: err}
+			}
+		} else {
+			windowID, paneID, err = e.tmux.NewWindow(ctx, s.Name, w.Name, filepath.Clean(first.Path), generate.ContentsCommand(first))
+			if err != nil {
+				return windowIDs, &StepError{Step: "new-window", Window: w, Pane: first.Index, Err: err}
+			}
+		}
+		windowIDs = append(windowIDs, windowID)
+
+		paneIDs := map[int]string{first.Index: paneID}
+		for _, p := range w.Panes[1:] {
+			id, err := e.tmux.SplitWindow(ctx, windowID, filepath.Clean(p.Path), generate.ContentsCommand(p))
+			if err != nil {
+				return windowIDs, &StepError{Step: "split-window", Window: w, Pane: p.Index, Err: err}
+			}
+			paneIDs[p.Index] = id
+		}
+		if err := e.tmux.SelectLayout(ctx, windowID, FitLayout(w.Layout, width, height)); err != nil {
+			return windowIDs, &StepError{Step: "select-layout", Window: w, Pane: -1, Err: err}
+		}
+		for _, p := range w.Panes {
+			if cmd := generate.ReplayCommand(p); cmd != "" {
+				if err := e.tmux.SendKeys(ctx, paneIDs[p.Index], cmd); err != nil {
+					return windowIDs, &StepError{Step: "send-keys", Window: w, Pane: p.Index, Err: err}
+				}
+			}
+		}
+		if id, ok := paneIDs[w.ActivePane]; ok {
+			if err := e.tmux.SelectPane(ctx, id); err != nil {
+				return windowIDs, &StepError{Step: "select-pane", Window: w, Pane: w.ActivePane, Err: err}
+			}
+		}
+		if w.Index == s.ActiveWindow {
+			activeWindowID = windowID
+		}
+	}
+	// A merge leaves the running session on the window it was on.
+	if activeWindowID != "" && !merge {
+		if err := e.tmux.SelectWindow(ctx, activeWindowID); err != nil {
+			return windowIDs, fmt.Errorf("select-window: %w", err)
+		}
+	}
+	return windowIDs, nil
+}
+
+// FitLayout rescales a saved window layout to width x height. Named layouts,
+// layouts that already fit and layouts that cannot shrink that far are
+// returned unchanged, as is everything when the size is unknown (0).
+func FitLayout(l string, width, height int) string {
+	if width <= 0 || height <= 0 {
+		return l
+	}
+	c, err := layout.Parse(l)
+	if err != nil || (c.Width == width && c.Height == height) {
+		return l
+	}
+	scaled, err := layout.Scale(c, width, height)
+	if err != nil {
+		return l
+	}
+	return scaled.String()
+}
@@ -0,0 +1,302 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"tforge/internal/onconflict"
+	"tforge/internal/snapshot"
+	"tforge/internal/tmux"
+)
+
+type fakeTmux struct {
+	width       int
+	height      int
+	existing    []string
+	windowNames []string
+	windows     int
+	panes       int
+	failOn      string
+	calls       []string
+	nextWindow  int
+	nextPane    int
+	killedNames []string
+}
+
+func (f *fakeTmux) record(step string, args ...string) error {
+	f.calls = append(f.calls, step+" "+strings.Join(args, " "))
+	if f.failOn == step {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func (f *fakeTmux) ids() (string, string) {
+	w, p := fmt.Sprintf("@%d", f.nextWindow), fmt.Sprintf("%%%d", f.nextPane)
+	f.nextWindow++
+	f.nextPane++
+	return w, p
+}
+
+func (f *fakeTmux) SessionExists(_ context.Context, session string) (bool, error) {
+	for _, s := range f.existing {
+		if s == session {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeTmux) ListWindows(context.Context, string) ([]tmux.WindowInfo, error) {
+	var out []tmux.WindowInfo
+	for i, name := range f.windowNames {
+		out = append(out, tmux.WindowInfo{Index: i, Name: name})
+	}
+	return out, nil
+}
+
+func (f *fakeTmux) KillWindow(_ context.Context, target string) error {
+	return f.record("kill-window", target)
+}
+
+func (f *fakeTmux) SessionSize(context.Context, string) (int, int, error) {
+	return f.windows, f.panes, nil
+}
+
+func (f *fakeTmux) NewSession(_ context.Context, session, window, dir, command string) (string, string, error) {
+	if err := f.record("new-session", session, window, dir); err != nil {
+		return "", "", err
+	}
+	w, p := f.ids()
+	return w, p, nil
+}
+
+func (f *fakeTmux) NewWindow(_ context.Context, session, window, dir, command string) (string, string, error) {
+	if err := f.record("new-window", session, window, dir); err != nil {
+		return "", "", err
+	}
+	w, p := f.ids()
+	return w, p, nil
+}
+
+func (f *fakeTmux) SplitWindow(_ context.Context, target, dir, command string) (string, error) {
+	if err := f.record("split-window", target, dir); err != nil {
+		return "", err
+	}
+	p := fmt.Sprintf("%%%d", f.nextPane)
+	f.nextPane++
+	return p, nil
+}
+
+func (f *fakeTmux) SelectLayout(_ context.Context, target, layout string) error {
+	return f.record("select-layout", target, layout)
+}
+
+func (f *fakeTmux) SelectPane(_ context.Context, target string) error {
+	return f.record("select-pane", target)
+}
+
+func (f *fakeTmux) SelectWindow(_ context.Context, target string) error {
+	return f.record("select-window", target)
+}
+
+func (f *fakeTmux) SendKeys(_ context.Context, target, text string) error {
+	return f.record("send-keys", target, text)
+}
+
+func (f *fakeTmux) ClientSize(context.Context) (int, int, error) {
+	return f.width, f.height, nil
+}
+
+func (f *fakeTmux) KillSession(_ context.Context, session string) error {
+	f.killedNames = append(f.killedNames, session)
+	return f.record("kill-session", session)
+}
+
+func testSession() snapshot.Session {
+	return snapshot.Session{
+		Name:         "hive",
+		ActiveWindow: 1,
+		Windows: []snapshot.Window{
+			{Index: 0, Name: "editor", Layout: "l0", Panes: []snapshot.Pane{{Index: 0, Path: "/repo"}}},
+			{Index: 1, Name: "logs", Layout: "l1", ActivePane: 1, Panes: []snapshot.Pane{
+				{Index: 0, Path: "/tmp"},
+				{Index: 1, Path: "/var/log", Argv: []string{"tail", "-f", "syslog"}},
+			}},
+		},
+	}
+}
+
+func TestRestoreDrivesTmux(t *testing.T) {
+	f := &fakeTmux{}
+	res, err := NewEngine(f).Restore(context.Background(), testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Created {
+		t.Fatal("expected session to be created")
+	}
+	want := []string{
+		"new-session hive editor /repo",
+		"select-layout @0 l0",
+		"select-pane %0",
+		"new-window hive logs /tmp",
+		"split-window @1 /var/log",
+		"select-layout @1 l1",
+		"send-keys %2 tail -f syslog",
+		"select-pane %2",
+		"select-window @1",
+	}
+	if got := strings.Join(f.calls, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected calls:\n%s", got)
+	}
+}
+
+func TestRestoreKeepsBusyExistingSession(t *testing.T) {
+	f := &fakeTmux{existing: []string{"hive"}, windows: 2, panes: 3}
+	res, err := NewEngine(f).Restore(context.Background(), testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Created || len(f.calls) != 0 {
+		t.Fatalf("expected existing session to be kept, calls=%q", f.calls)
+	}
+}
+
+func TestRestoreCleansUpOnFailure(t *testing.T) {
+	f := &fakeTmux{failOn: "split-window"}
+	_, err := NewEngine(f).Restore(context.Background(), testSession())
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) {
+		t.Fatalf("expected StepError, got %v", err)
+	}
+	if stepErr.Step != "split-window" || stepErr.Window.Name != "logs" || stepErr.Pane != 1 {
+		t.Fatalf("unexpected step error: %v", stepErr)
+	}
+	if len(f.killedNames) != 1 || f.killedNames[0] != "hive" {
+		t.Fatalf("expected partial session to be killed, got %q", f.killedNames)
+	}
+	if !strings.Contains(err.Error(), "window 1 (logs) pane 1: split-window: boom") {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+}
+
+func TestRestoreScalesLayoutsToClient(t *testing.T) {
+	s := testSession()
+	s.Windows[1].Layout = "306f,160x40,0,0{80x40,0,0,0,79x40,81,0[79x20,81,0,1,79x19,81,21,2]}"
+	f := &fakeTmux{width: 80, height: 20}
+	if _, err := NewEngine(f).Restore(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	want := "select-layout @1 " + FitLayout(s.Windows[1].Layout, 80, 20)
+	if !strings.Contains(strings.Join(f.calls, "\n"), want) {
+		t.Fatalf("expected %q in calls:\n%s", want, strings.Join(f.calls, "\n"))
+	}
+	if !strings.Contains(want, ",80x20,0,0{40x20,0,0,0,39x20,41,0[") {
+		t.Fatalf("unexpected scaled layout %q", want)
+	}
+}
+
+func TestFitLayoutLeavesUnscalableLayouts(t *testing.T) {
+	for _, l := range []string{"tiled", "l0", "b25f,80x24,0,0,2"} {
+		if got := FitLayout(l, 80, 24); got != l {
+			t.Fatalf("FitLayout(%q) = %q", l, got)
+		}
+	}
+	if got := FitLayout("b25f,80x24,0,0,2", 0, 0); got != "b25f,80x24,0,0,2" {
+		t.Fatalf("expected unknown size to keep the layout, got %q", got)
+	}
+}
+
+func TestRestoreConflictStrategies(t *testing.T) {
+	cases := []struct {
+		conflict onconflict.Strategy
+		session  string
+		created  bool
+		first    string
+	}{
+		{onconflict.Replace, "hive", true, "kill-session hive"},
+		{onconflict.Rename, "hive-3", true, "new-session hive-3 editor /repo"},
+		{onconflict.Merge, "hive", false, "new-window hive logs /tmp"},
+	}
+	for _, c := range cases {
+		f := &fakeTmux{existing: []string{"hive", "hive-2"}, windowNames: []string{"editor", "shell"}, windows: 2, panes: 2}
+		e := NewEngine(f)
+		e.OnConflict = c.conflict
+		res, err := e.Restore(context.Background(), testSession())
+		if err != nil {
+			t.Fatalf("%s: %v", c.conflict, err)
+		}
+		if res.Session != c.session || res.Created != c.created || len(f.calls) == 0 || f.calls[0] != c.first {
+			t.Fatalf("%s: unexpected result %+v, calls:\n%s", c.conflict, res, strings.Join(f.calls, "\n"))
+		}
+	}
+}
+
+func TestRestoreMergeAddsOnlyMissingWindows(t *testing.T) {
+	f := &fakeTmux{existing: []string{"hive"}, windowNames: []string{"editor"}}
+	e := NewEngine(f)
+	e.OnConflict = onconflict.Merge
+	res, err := e.Restore(context.Background(), testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Added) != 1 || res.Added[0] != "logs" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	got := strings.Join(f.calls, "\n")
+	if strings.Contains(got, "editor") || strings.Contains(got, "select-window") {
+		t.Fatalf("expected only the logs window to be added, calls:\n%s", got)
+	}
+
+	f = &fakeTmux{existing: []string{"hive"}, windowNames: []string{"editor", "logs"}}
+	e = NewEngine(f)
+	e.OnConflict = onconflict.Merge
+	if res, err := e.Restore(context.Background(), testSession()); err != nil || len(res.Added) != 0 || len(f.calls) != 0 {
+		t.Fatalf("expected nothing to merge, got %+v %v %q", res, err, f.calls)
+	}
+}
+
+func TestRestoreMergeAddsUnnamedWindows(t *testing.T) {
+	f := &fakeTmux{existing: []string{"hive"}, windowNames: []string{"editor", "logs", ""}}
+	e := NewEngine(f)
+	e.OnConflict = onconflict.Merge
+	s := testSession()
+	s.Windows[1].Name = ""
+	res, err := e.Restore(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Added) != 1 || res.Added[0] != "" {
+		t.Fatalf("expected the unnamed window to be added, got %+v", res)
+	}
+}
+
+func TestRestoreMergeRemovesAddedWindowsOnFailure(t *testing.T) {
+	f := &fakeTmux{existing: []string{"hive"}, failOn: "send-keys"}
+	e := NewEngine(f)
+	e.OnConflict = onconflict.Merge
+	_, err := e.Restore(context.Background(), testSession())
+	if err == nil || len(f.killedNames) != 0 {
+		t.Fatalf("expected an error without killing the session, got %v, killed %q", err, f.killedNames)
+	}
+	got := strings.Join(f.calls, "\n")
+	if !strings.HasSuffix(got, "kill-window @0\nkill-window @1") {
+		t.Fatalf("expected the added windows to be removed, calls:\n%s", got)
+	}
+}
+
+func TestRestoreFailsOnConflict(t *testing.T) {
+	f := &fakeTmux{existing: []string{"hive"}}
+	e := NewEngine(f)
+	e.OnConflict = onconflict.Fail
+	if _, err := e.Restore(context.Background(), testSession()); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if len(f.calls) != 0 {
+		t.Fatalf("expected tmux to be left alone, got %q", f.calls)
+	}
+}
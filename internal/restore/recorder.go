@@ -0,0 +1,133 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tforge/internal/shell"
+	"tforge/internal/tmux"
+)
+
+// Recorder is a TmuxDriver for dry runs. It answers queries from the driver
+// it wraps, so conflict decisions match the running server, but only records
+// the commands that would change tmux. Steps holds those commands as tmux
+// command lines, with notes on what was found starting with "#". Windows and
+// panes it pretends to create get placeholder ids such as @new1 and %new2.
+type Recorder struct {
+	TmuxDriver
+	Steps []string
+
+	windows, panes int
+}
+
+func NewRecorder(tmux TmuxDriver) *Recorder {
+	return &Recorder{TmuxDriver: tmux}
+}
+
+func (r *Recorder) note(format string, args ...any) {
+	r.Steps = append(r.Steps, "# "+fmt.Sprintf(format, args...))
+}
+
+func (r *Recorder) run(args ...string) {
+	r.Steps = append(r.Steps, "tmux "+shell.Join(args))
+}
+
+func (r *Recorder) newWindowID() string {
+	r.windows++
+	return fmt.Sprintf("@new%d", r.windows)
+}
+
+func (r *Recorder) newPaneID() string {
+	r.panes++
+	return fmt.Sprintf("%%new%d", r.panes)
+}
+
+func (r *Recorder) SessionExists(ctx context.Context, session string) (bool, error) {
+	exists, err := r.TmuxDriver.SessionExists(ctx, session)
+	if err == nil && exists {
+		r.note("session %s already exists", session)
+	}
+	return exists, err
+}
+
+func (r *Recorder) SessionSize(ctx context.Context, session string) (int, int, error) {
+	windows, panes, err := r.TmuxDriver.SessionSize(ctx, session)
+	if err == nil {
+		r.note("session %s has %d window(s) and %d pane(s)", session, windows, panes)
+	}
+	return windows, panes, err
+}
+
+func (r *Recorder) ListWindows(ctx context.Context, session string) ([]tmux.WindowInfo, error) {
+	windows, err := r.TmuxDriver.ListWindows(ctx, session)
+	if err == nil {
+		names := make([]string, len(windows))
+		for i, w := range windows {
+			names[i] = w.Name
+		}
+		r.note("session %s has windows: %s", session, strings.Join(names, ", "))
+	}
+	return windows, err
+}
+
+func (r *Recorder) ClientSize(ctx context.Context) (int, int, error) {
+	width, height, err := r.TmuxDriver.ClientSize(ctx)
+	if err == nil && width > 0 && height > 0 {
+		r.note("layouts are fitted to %dx%d", width, height)
+	}
+	return width, height, err
+}
+
+func (r *Recorder) NewSession(_ context.Context, session, window, dir, command string) (string, string, error) {
+	r.run(withCommand([]string{"new-session", "-d", "-s", session, "-n", window, "-c", dir}, command)...)
+	return r.newWindowID(), r.newPaneID(), nil
+}
+
+func (r *Recorder) NewWindow(_ context.Context, session, window, dir, command string) (string, string, error) {
+	r.run(withCommand([]string{"new-window", "-d", "-t", session + ":", "-n", window, "-c", dir}, command)...)
+	return r.newWindowID(), r.newPaneID(), nil
+}
+
+func (r *Recorder) SplitWindow(_ context.Context, target, dir, command string) (string, error) {
+	r.run(withCommand([]string{"split-window", "-d", "-t", target, "-c", dir}, command)...)
+	return r.newPaneID(), nil
+}
+
+func (r *Recorder) SelectLayout(_ context.Context, target, layout string) error {
+	r.run("select-layout", "-t", target, layout)
+	return nil
+}
+
+func (r *Recorder) SelectPane(_ context.Context, target string) error {
+	r.run("select-pane", "-t", target)
+	return nil
+}
+
+func (r *Recorder) SelectWindow(_ context.Context, target string) error {
+	r.run("select-window", "-t", target)
+	return nil
+}
+
+func (r *Recorder) SendKeys(_ context.Context, target, text string) error {
+	r.run("send-keys", "-l", "-t", target, "--", text)
+	r.run("send-keys", "-t", target, "Enter")
+	return nil
+}
+
+func (r *Recorder) KillSession(_ context.Context, session string) error {
+	r.run("kill-session", "-t", session)
+	return nil
+}
+
+func (r *Recorder) KillWindow(_ context.Context, target string) error {
+	r.run("kill-window", "-t", target)
+	return nil
+}
+
+func withCommand(args []string, command string) []string {
+	if command == "" {
+		return args
+	}
+	return append(args, command)
+}
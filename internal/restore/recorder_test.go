@@ -0,0 +1,48 @@
+package restore
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRecorderPlansWithoutChangingTmux(t *testing.T) {
+	f := &fakeTmux{}
+	rec := NewRecorder(f)
+	if _, err := NewEngine(rec).Restore(context.Background(), testSession()); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.calls) != 0 {
+		t.Fatalf("expected no tmux changes, got %q", f.calls)
+	}
+	want := []string{
+		"tmux new-session -d -s hive -n editor -c /repo",
+		"tmux select-layout -t @new1 l0",
+		"tmux select-pane -t %new1",
+		"tmux new-window -d -t hive: -n logs -c /tmp",
+		"tmux split-window -d -t @new2 -c /var/log",
+		"tmux select-layout -t @new2 l1",
+		"tmux send-keys -l -t %new3 -- 'tail -f syslog'",
+		"tmux send-keys -t %new3 Enter",
+		"tmux select-pane -t %new3",
+		"tmux select-window -t @new2",
+	}
+	if got := strings.Join(rec.Steps, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s", got)
+	}
+}
+
+func TestRecorderNotesConflictDecision(t *testing.T) {
+	rec := NewRecorder(&fakeTmux{existing: []string{"hive"}, windows: 1, panes: 1})
+	if _, err := NewEngine(rec).Restore(context.Background(), testSession()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"# session hive already exists",
+		"# session hive has 1 window(s) and 1 pane(s)",
+		"tmux kill-session -t hive",
+	}
+	if got := strings.Join(rec.Steps[:3], "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(rec.Steps, "\n"))
+	}
+}
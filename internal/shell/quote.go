@@ -0,0 +1,44 @@
+package shell
+
+import "strings"
+
+// Quote returns s in a form that a POSIX shell parses back to exactly s as a
+// single word. Words made only of characters that are never special are left
+// bare; everything else is wrapped in single quotes, inside which nothing is
+// interpreted; an embedded single quote closes the quoting, is escaped with a
+// backslash and reopens it.
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if isSafe(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes each argument and joins them into a command line that runs argv.
+func Join(argv []string) string {
+	quoted := make([]string, 0, len(argv))
+	for i, arg := range argv {
+		// A bare NAME=value as the first word would be parsed as an assignment.
+		if i == 0 && strings.Contains(arg, "=") {
+			quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+			continue
+		}
+		quoted = append(quoted, Quote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func isSafe(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("-_./:=@%+,", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var nasty = []string{
+	"",
+	"plain",
+	"with space",
+	"$HOME",
+	"${HOME:-x}",
+	"$(touch /tmp/tforge-pwned)",
+	"`id`",
+	"it's",
+	"'",
+	"''",
+	`"double"`,
+	`back\slash`,
+	"semi;colon && rm -rf /",
+	"pipe|worker",
+	"glob*?[a]",
+	"~user",
+	"-n",
+	"!event",
+	"new\nline",
+	"tab\there",
+	"héllo wörld ✓",
+	"NAME=value",
+}
+
+// bashArgs runs words through a real bash parse and returns the resulting argv.
+func bashArgs(t *testing.T, words string) []string {
+	t.Helper()
+	out, err := exec.Command("bash", "--norc", "--noprofile", "-c", `printf '%s\0' `+words).Output()
+	if err != nil {
+		t.Fatalf("bash failed for %q: %v", words, err)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+}
+
+func requireBash(t testing.TB) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+}
+
+func TestQuoteRoundTripsThroughBash(t *testing.T) {
+	requireBash(t)
+	for _, s := range nasty {
+		got := bashArgs(t, Quote(s))
+		if len(got) != 1 || got[0] != s {
+			t.Fatalf("Quote(%q) = %s parsed back as %q", s, Quote(s), got)
+		}
+	}
+}
+
+func TestJoinRoundTripsThroughBash(t *testing.T) {
+	requireBash(t)
+	got := bashArgs(t, Join(nasty[1:]))
+	if strings.Join(got, "\x00") != strings.Join(nasty[1:], "\x00") {
+		t.Fatalf("Join parsed back as %q", got)
+	}
+}
+
+func TestJoinQuotesLeadingAssignment(t *testing.T) {
+	if got := Join([]string{"A=b", "c=d"}); got != "'A=b' c=d" {
+		t.Fatalf("unexpected join: %s", got)
+	}
+}
+
+func FuzzQuote(f *testing.F) {
+	requireBash(f)
+	for _, s := range nasty {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if strings.ContainsRune(s, 0) {
+			t.Skip("NUL cannot appear in a shell word")
+		}
+		got := bashArgs(t, Quote(s))
+		if len(got) != 1 || got[0] != s {
+			t.Fatalf("Quote(%q) = %s parsed back as %q", s, Quote(s), got)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnterminated is returned by Split when s ends inside a quoted string or
+// after a trailing backslash; the caller may append the next line and retry.
+var ErrUnterminated = errors.New("unterminated quoted string")
+
+// Split breaks a command line into words the way a POSIX shell would, for the
+// subset of the grammar that Quote and Join produce: bare words, single
+// quotes, double quotes and backslash escapes. Expansions such as $VAR are
+// not performed and are returned literally.
+func Split(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, ErrUnterminated
+			}
+			i++
+			if s[i] != '\n' {
+				cur.WriteByte(s[i])
+				inWord = true
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, ErrUnterminated
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, ErrUnterminated
+			}
+			inWord = true
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplitInvertsJoin(t *testing.T) {
+	got, err := Split(Join(nasty[1:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "\x00") != strings.Join(nasty[1:], "\x00") {
+		t.Fatalf("Split(Join) = %q", got)
+	}
+}
+
+func TestSplitDoubleQuotesAndEscapes(t *testing.T) {
+	got, err := Split(`tmux has-session -t "$SESSION" a\ b "x\"y"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tmux", "has-session", "-t", "$SESSION", "a b", `x"y`}
+	if strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSplitReportsUnterminatedQuote(t *testing.T) {
+	for _, s := range []string{"echo 'abc", `echo "abc`, `echo abc\`} {
+		if _, err := Split(s); !errors.Is(err, ErrUnterminated) {
+			t.Fatalf("Split(%q): expected ErrUnterminated, got %v", s, err)
+		}
+	}
+}
+
+func FuzzSplitQuote(f *testing.F) {
+	for _, s := range nasty {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := Split(Quote(s))
+		if err != nil || len(got) != 1 || got[0] != s {
+			t.Fatalf("Split(Quote(%q)) = %q, %v", s, got, err)
+		}
+	})
+}
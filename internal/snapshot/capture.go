@@ -3,73 +3,100 @@ package snapshot
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
+
+	"tforge/internal/tmux"
 )
 
 type TmuxReader interface {
-	ListWindows(ctx context.Context, session string) ([]string, error)
-	ListPanes(ctx context.Context, target string) ([]string, error)
+	ListWindows(ctx context.Context, session string) ([]tmux.WindowInfo, error)
+	ListPanes(ctx context.Context, target string) ([]tmux.PaneInfo, error)
+	CapturePane(ctx context.Context, target string) (string, error)
 }
 
 type Session struct {
-	Name          string
-	Windows       []Window
-	ActiveWindow  int
-	ActivePaneIDs map[int]int
+	Name          string      `json:"name"`
+	Windows       []Window    `json:"windows"`
+	ActiveWindow  int         `json:"active_window"`
+	ActivePaneIDs map[int]int `json:"active_pane_ids,omitempty"`
 }
 
 type Window struct {
-	Index      int
-	Name       string
-	Layout     string
-	Panes      []Pane
-	ActivePane int
+	Index      int    `json:"index"`
+	Name       string `json:"name"`
+	Layout     string `json:"layout"`
+	Panes      []Pane `json:"panes"`
+	ActivePane int    `json:"active_pane"`
 }
 
 type Pane struct {
-	Index int
-	ID    string
-	Path  string
+	Index        int      `json:"index"`
+	ID           string   `json:"id,omitempty"`
+	Path         string   `json:"path"`
+	Command      string   `json:"command,omitempty"`
+	StartCommand string   `json:"start_command,omitempty"`
+	Argv         []string `json:"argv,omitempty"`
+	Contents     string   `json:"-"`
+	ContentsPath string   `json:"contents_path,omitempty"`
 }
 
 type Capturer struct {
 	tmux TmuxReader
+	proc ProcReader
+
+	// SkipCommands disables recording of the commands running in each pane,
+	// so restored panes come back as bare shells.
+	SkipCommands bool
+	// Contents captures each pane's scrollback so it can be replayed on restore.
+	Contents bool
 }
 
 func NewCapturer(tmux TmuxReader) *Capturer {
-	return &Capturer{tmux: tmux}
+	return &Capturer{tmux: tmux, proc: NewProcReader()}
 }
 
 func (c *Capturer) CaptureSession(ctx context.Context, session string) (Session, error) {
-	windowLines, err := c.tmux.ListWindows(ctx, session)
+	windows, err := c.tmux.ListWindows(ctx, session)
 	if err != nil {
 		return Session{}, err
 	}
-	if len(windowLines) == 0 {
+	if len(windows) == 0 {
 		return Session{}, fmt.Errorf("session %q has no windows", session)
 	}
 
 	snap := Session{Name: session, ActivePaneIDs: map[int]int{}}
-	for _, line := range windowLines {
-		win, activeWindow, err := parseWindow(line)
-		if err != nil {
-			return Session{}, err
-		}
-		if activeWindow {
+	for _, w := range windows {
+		win := Window{Index: w.Index, Name: w.Name, Layout: w.Layout}
+		if w.Active {
 			snap.ActiveWindow = win.Index
 		}
-		paneLines, err := c.tmux.ListPanes(ctx, fmt.Sprintf("%s:%d", session, win.Index))
+		panes, err := c.tmux.ListPanes(ctx, w.ID)
 		if err != nil {
 			return Session{}, err
 		}
-		for _, pLine := range paneLines {
-			pane, activePane, err := parsePane(pLine)
-			if err != nil {
-				return Session{}, err
+		for _, p := range panes {
+			pane := Pane{Index: p.Index, ID: p.ID, Path: p.Path}
+			if !c.SkipCommands {
+				pane.Command = p.CurrentCommand
+				pane.StartCommand = unquoteStartCommand(p.StartCommand)
+			}
+			if !c.SkipCommands && p.PID > 0 {
+				// Argv is a bonus: there is no /proc on macOS or the BSDs, and
+				// the pane may exit before it is read. Restore then falls back
+				// to the current or start command.
+				if argv, err := c.proc.ForegroundArgv(p.PID); err == nil {
+					pane.Argv = argv
+				}
+			}
+			if c.Contents {
+				text, err := c.tmux.CapturePane(ctx, pane.ID)
+				if err != nil {
+					return Session{}, err
+				}
+				pane.Contents = text
 			}
 			win.Panes = append(win.Panes, pane)
-			if activePane {
+			if p.Active {
 				win.ActivePane = pane.Index
 				snap.ActivePaneIDs[win.Index] = pane.Index
 			}
@@ -79,28 +106,19 @@ func (c *Capturer) CaptureSession(ctx context.Context, session string) (Session,
 	return snap, nil
 }
 
-func parseWindow(line string) (Window, bool, error) {
-	parts := strings.Split(line, "|")
-	if len(parts) != 4 {
-		return Window{}, false, fmt.Errorf("invalid tmux window row: %q", line)
+// unquoteStartCommand undoes the double quoting tmux applies when a pane was
+// started with a single shell-command argument, e.g. "tail -f app.log".
+func unquoteStartCommand(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
 	}
-	index, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return Window{}, false, err
-	}
-	active := parts[3] == "1"
-	return Window{Index: index, Name: parts[1], Layout: parts[2]}, active, nil
-}
-
-func parsePane(line string) (Pane, bool, error) {
-	parts := strings.Split(line, "|")
-	if len(parts) != 4 {
-		return Pane{}, false, fmt.Errorf("invalid tmux pane row: %q", line)
-	}
-	index, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return Pane{}, false, err
+	var b strings.Builder
+	inner := s[1 : len(s)-1]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) {
+			i++
+		}
+		b.WriteByte(inner[i])
 	}
-	active := parts[3] == "1"
-	return Pane{Index: index, ID: parts[1], Path: parts[2]}, active, nil
+	return b.String()
 }
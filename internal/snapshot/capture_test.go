@@ -2,24 +2,44 @@ package snapshot
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+
+	"tforge/internal/tmux"
 )
 
 type fakeTmux struct{}
 
-func (fakeTmux) ListWindows(ctx context.Context, session string) ([]string, error) {
-	return []string{"0|editor|a,b,c|1", "1|logs|d,e,f|0"}, nil
+func (fakeTmux) ListWindows(ctx context.Context, session string) ([]tmux.WindowInfo, error) {
+	return []tmux.WindowInfo{
+		{ID: "@1", Index: 0, Name: "editor", Layout: "a,b,c", Active: true},
+		{ID: "@2", Index: 1, Name: "api|worker", Layout: "d,e,f"},
+	}, nil
 }
 
-func (fakeTmux) ListPanes(ctx context.Context, target string) ([]string, error) {
-	if target == "hive:0" {
-		return []string{"0|%1|/repo|1", "1|%2|/repo|0"}, nil
+func (fakeTmux) ListPanes(ctx context.Context, target string) ([]tmux.PaneInfo, error) {
+	if target == "@1" {
+		return []tmux.PaneInfo{
+			{Index: 0, ID: "%1", Path: "/repo", Active: true, PID: 100, CurrentCommand: "bash"},
+			{Index: 1, ID: "%2", Path: "/repo", PID: 200, CurrentCommand: "node"},
+		}, nil
 	}
-	return []string{"0|%3|/tmp|1"}, nil
+	return []tmux.PaneInfo{{Index: 0, ID: "%3", Path: "/tmp/a|b", Active: true, PID: 300, CurrentCommand: "tail", StartCommand: `"tail -f a.log | grep err"`}}, nil
+}
+
+func (fakeTmux) CapturePane(ctx context.Context, target string) (string, error) {
+	return "history of " + target, nil
+}
+
+type fakeProc map[int][]string
+
+func (f fakeProc) ForegroundArgv(pid int) ([]string, error) {
+	return f[pid], nil
 }
 
 func TestCaptureSession(t *testing.T) {
 	c := NewCapturer(fakeTmux{})
+	c.proc = fakeProc{}
 	s, err := c.CaptureSession(context.Background(), "hive")
 	if err != nil {
 		t.Fatal(err)
@@ -34,3 +54,47 @@ func TestCaptureSession(t *testing.T) {
 		t.Fatalf("expected active pane 0, got %d", s.Windows[0].ActivePane)
 	}
 }
+
+func TestCaptureSessionRecordsCommands(t *testing.T) {
+	c := NewCapturer(fakeTmux{})
+	c.proc = fakeProc{200: {"npm", "run", "dev"}}
+	s, err := c.CaptureSession(context.Background(), "hive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Windows[0].Panes[1].Argv; len(got) != 3 || got[2] != "dev" {
+		t.Fatalf("unexpected argv: %q", got)
+	}
+	if got := s.Windows[1].Panes[0].StartCommand; got != "tail -f a.log | grep err" {
+		t.Fatalf("unexpected start command: %q", got)
+	}
+
+	c.SkipCommands = true
+	s, err = c.CaptureSession(context.Background(), "hive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range s.Windows {
+		for _, p := range w.Panes {
+			if len(p.Argv) != 0 || p.Command != "" || p.StartCommand != "" {
+				t.Fatalf("expected no commands with SkipCommands, got %+v", p)
+			}
+		}
+	}
+}
+
+func TestCaptureSessionWithoutProc(t *testing.T) {
+	c := NewCapturer(fakeTmux{})
+	c.proc = procFS{root: filepath.Join(t.TempDir(), "missing")}
+	s, err := c.CaptureSession(context.Background(), "hive")
+	if err != nil {
+		t.Fatalf("expected capture to succeed without /proc, got %v", err)
+	}
+	p := s.Windows[0].Panes[1]
+	if p.Argv != nil || p.Command != "node" {
+		t.Fatalf("expected pane_current_command fallback, got %+v", p)
+	}
+	if got := s.Windows[1].Panes[0].StartCommand; got != "tail -f a.log | grep err" {
+		t.Fatalf("unexpected start command: %q", got)
+	}
+}
@@ -0,0 +1,32 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tforge/internal/fsutil"
+)
+
+// SaveContents writes the captured scrollback of every pane into dir and
+// records the file on the pane.
+func SaveContents(dir string, s *Session) error {
+	for wi := range s.Windows {
+		w := &s.Windows[wi]
+		for pi := range w.Panes {
+			p := &w.Panes[pi]
+			if p.Contents == "" {
+				continue
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			path := filepath.Join(dir, fmt.Sprintf("%d.%d.txt", w.Index, p.Index))
+			if err := fsutil.WriteFileAtomic(path, []byte(p.Contents+"\n"), 0o644); err != nil {
+				return err
+			}
+			p.ContentsPath = path
+		}
+	}
+	return nil
+}
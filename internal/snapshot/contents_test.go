@@ -0,0 +1,34 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveContents(t *testing.T) {
+	c := NewCapturer(fakeTmux{})
+	c.proc = fakeProc{}
+	c.Contents = true
+	s, err := c.CaptureSession(context.Background(), "hive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "hive")
+	if err := SaveContents(dir, &s); err != nil {
+		t.Fatal(err)
+	}
+	p := s.Windows[0].Panes[1]
+	if p.ContentsPath != filepath.Join(dir, "0.1.txt") {
+		t.Fatalf("unexpected contents path %q", p.ContentsPath)
+	}
+	b, err := os.ReadFile(p.ContentsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "history of %2\n" {
+		t.Fatalf("unexpected contents %q", b)
+	}
+}
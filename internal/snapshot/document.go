@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tforge/internal/fsutil"
+)
+
+// DocumentVersion is the schema version written by WriteDocument. Bump it
+// whenever the on-disk shape of Document changes incompatibly.
+const DocumentVersion = 1
+
+// Document is the declarative, durable form of a capture. Scripts are derived
+// from it, so they can be regenerated whenever the generator changes.
+type Document struct {
+	Version    int       `json:"version"`
+	CapturedAt time.Time `json:"captured_at"`
+	Session    Session   `json:"session"`
+}
+
+func NewDocument(s Session, capturedAt time.Time) Document {
+	return Document{Version: DocumentVersion, CapturedAt: capturedAt, Session: s}
+}
+
+func ReadDocument(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	var d Document
+	if err := json.Unmarshal(b, &d); err != nil {
+		return Document{}, fmt.Errorf("parse snapshot %s: %w", path, err)
+	}
+	switch {
+	case d.Version == 0:
+		return Document{}, fmt.Errorf("snapshot %s has no schema version", path)
+	case d.Version > DocumentVersion:
+		return Document{}, fmt.Errorf("snapshot %s has schema version %d; this tforge supports up to %d, please upgrade", path, d.Version, DocumentVersion)
+	}
+	return d, nil
+}
+
+func WriteDocument(path string, d Document) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	d.Version = DocumentVersion
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, append(b, '\n'), 0o644)
+}
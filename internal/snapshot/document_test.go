@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hive.json")
+	in := NewDocument(Session{
+		Name:         "hive",
+		ActiveWindow: 1,
+		Windows: []Window{
+			{Index: 0, Name: "editor", Layout: "a,b,c", Panes: []Pane{{Index: 0, Path: "/repo", Contents: "not persisted"}}},
+			{Index: 1, Name: "logs", Layout: "d,e,f", ActivePane: 1, Panes: []Pane{{Index: 0, Path: "/tmp"}, {Index: 1, Path: "/tmp", Argv: []string{"tail", "-f", "x"}}}},
+		},
+	}, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err := WriteDocument(path, in); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ReadDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Version != DocumentVersion || out.Session.Name != "hive" || out.Session.ActiveWindow != 1 {
+		t.Fatalf("unexpected document: %+v", out)
+	}
+	if got := out.Session.Windows[1].Panes[1].Argv; len(got) != 3 {
+		t.Fatalf("unexpected argv: %q", got)
+	}
+	if out.Session.Windows[0].Panes[0].Contents != "" {
+		t.Fatal("pane contents must not be embedded in the document")
+	}
+}
+
+func TestReadDocumentRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hive.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "session": {"name": "hive"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadDocument(path)
+	if err == nil || !strings.Contains(err.Error(), "upgrade") {
+		t.Fatalf("expected upgrade error, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ProcReader resolves the argv of the foreground process running under a
+// pane's shell. It returns nil when the shell itself is in the foreground.
+type ProcReader interface {
+	ForegroundArgv(pid int) ([]string, error)
+}
+
+type procFS struct {
+	root string
+}
+
+func NewProcReader() ProcReader {
+	return procFS{root: "/proc"}
+}
+
+type procStat struct {
+	pid   int
+	ppid  int
+	pgrp  int
+	tpgid int
+}
+
+func (p procFS) ForegroundArgv(pid int) ([]string, error) {
+	shell, err := p.stat(pid)
+	if err != nil {
+		return nil, err
+	}
+	if shell.tpgid <= 0 || shell.tpgid == shell.pgrp {
+		return nil, nil
+	}
+
+	children, err := p.children()
+	if err != nil {
+		return nil, err
+	}
+	var fallback int
+	queue := []int{pid}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, child := range children[cur] {
+			if child.pid == shell.tpgid {
+				return p.cmdline(child.pid)
+			}
+			if fallback == 0 && child.pgrp == shell.tpgid {
+				fallback = child.pid
+			}
+			queue = append(queue, child.pid)
+		}
+	}
+	if fallback == 0 {
+		return nil, nil
+	}
+	return p.cmdline(fallback)
+}
+
+func (p procFS) children() (map[int][]procStat, error) {
+	entries, err := os.ReadDir(p.root)
+	if err != nil {
+		return nil, err
+	}
+	children := map[int][]procStat{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		st, err := p.stat(pid)
+		if err != nil {
+			// Processes routinely exit while /proc is being walked.
+			continue
+		}
+		children[st.ppid] = append(children[st.ppid], st)
+	}
+	return children, nil
+}
+
+func (p procFS) stat(pid int) (procStat, error) {
+	b, err := os.ReadFile(filepath.Join(p.root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+	// comm (field 2) may contain spaces and parentheses; fields resume after
+	// the last closing parenthesis.
+	end := bytes.LastIndexByte(b, ')')
+	if end < 0 {
+		return procStat{}, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	fields := strings.Fields(string(b[end+1:]))
+	if len(fields) < 6 {
+		return procStat{}, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	ppid, err1 := strconv.Atoi(fields[1])
+	pgrp, err2 := strconv.Atoi(fields[2])
+	tpgid, err3 := strconv.Atoi(fields[5])
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return procStat{}, fmt.Errorf("invalid stat for pid %d: %w", pid, err)
+	}
+	return procStat{pid: pid, ppid: ppid, pgrp: pgrp, tpgid: tpgid}, nil
+}
+
+func (p procFS) cmdline(pid int) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join(p.root, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimRight(b, "\x00")
+	if len(b) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(b), "\x00"), nil
+}
@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeProc(t *testing.T, root string, pid, ppid, pgrp, tpgid int, argv ...string) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stat := strconv.Itoa(pid) + " (some (odd) comm) S " + strings.Join([]string{
+		strconv.Itoa(ppid), strconv.Itoa(pgrp), "1", "34816", strconv.Itoa(tpgid),
+	}, " ") + " 4194560 0 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmdline := strings.Join(argv, "\x00") + "\x00"
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForegroundArgv(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, 10, 1, 10, 12, "-bash")
+	writeProc(t, root, 11, 10, 11, 12, "sleep", "100")
+	writeProc(t, root, 12, 10, 12, 12, "tail", "-f", "/var/log/app log")
+	writeProc(t, root, 20, 1, 20, 20, "-zsh")
+
+	p := procFS{root: root}
+	argv, err := p.ForegroundArgv(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(argv, "|") != "tail|-f|/var/log/app log" {
+		t.Fatalf("unexpected argv: %q", argv)
+	}
+
+	argv, err = p.ForegroundArgv(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if argv != nil {
+		t.Fatalf("expected no foreground command for idle shell, got %q", argv)
+	}
+}
@@ -0,0 +1,134 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Rows returned by list commands are described by structs whose fields carry
+// a `tmux:"<format variable>"` tag. Every variable is requested through the
+// #{q:...} modifier, which backslash-escapes '|' and '\', so an unescaped '|'
+// is an unambiguous field terminator whatever the names and paths contain.
+// #{q:...} leaves newlines alone, so a row ends only at the newline after its
+// last field's terminator; any other newline is part of a name or path.
+
+type SessionInfo struct {
+	Name string `tmux:"session_name"`
+}
+
+type WindowInfo struct {
+	ID     string `tmux:"window_id"`
+	Index  int    `tmux:"window_index"`
+	Name   string `tmux:"window_name"`
+	Layout string `tmux:"window_layout"`
+	Active bool   `tmux:"window_active"`
+}
+
+type PaneInfo struct {
+	Index          int    `tmux:"pane_index"`
+	ID             string `tmux:"pane_id"`
+	Path           string `tmux:"pane_current_path"`
+	Active         bool   `tmux:"pane_active"`
+	PID            int    `tmux:"pane_pid"`
+	CurrentCommand string `tmux:"pane_current_command"`
+	StartCommand   string `tmux:"pane_start_command"`
+}
+
+// query runs a tmux list command with a format built from T's tags and
+// decodes each output row into a T.
+func query[T any](ctx context.Context, r Runner, args ...string) ([]T, error) {
+	format, err := formatFor[T]()
+	if err != nil {
+		return nil, err
+	}
+	out, err := r.Run(ctx, append(args, "-F", format)...)
+	if err != nil {
+		return nil, err
+	}
+	var zero T
+	fields, err := splitRows(out, reflect.TypeOf(zero).NumField())
+	if err != nil {
+		return nil, fmt.Errorf("invalid tmux %s output: %w", reflect.TypeOf(zero).Name(), err)
+	}
+	rows := make([]T, len(fields))
+	for i := range fields {
+		if err := decodeRow(fields[i], &rows[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func formatFor[T any]() (string, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	parts := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("tmux")
+		if name == "" {
+			return "", fmt.Errorf("tmux: field %s.%s has no tmux tag", t.Name(), t.Field(i).Name)
+		}
+		parts = append(parts, "#{q:"+name+"}|")
+	}
+	return strings.Join(parts, ""), nil
+}
+
+func decodeRow(fields []string, dst any) error {
+	v := reflect.ValueOf(dst).Elem()
+	if len(fields) != v.NumField() {
+		return fmt.Errorf("invalid tmux %s row: expected %d fields, got %d: %q", v.Type().Name(), v.NumField(), len(fields), fields)
+	}
+	for i, raw := range fields {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("invalid tmux %s row: field %s: %w", v.Type().Name(), v.Type().Field(i).Tag.Get("tmux"), err)
+			}
+			f.SetInt(int64(n))
+		case reflect.Bool:
+			f.SetBool(raw == "1")
+		default:
+			return fmt.Errorf("tmux: unsupported field type %s", f.Type())
+		}
+	}
+	return nil
+}
+
+// splitRows splits the output of a format built by formatFor into rows of n
+// fields and removes the backslash escaping added by #{q:...}.
+func splitRows(out string, n int) ([][]string, error) {
+	var rows [][]string
+	var row []string
+	var cur strings.Builder
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '\\' && i+1 < len(out):
+			i++
+			cur.WriteByte(out[i])
+		case c == '|':
+			row = append(row, cur.String())
+			cur.Reset()
+			if len(row) < n {
+				continue
+			}
+			if i+1 < len(out) && out[i+1] != '\n' {
+				return nil, fmt.Errorf("row %d has more than %d fields: %q", len(rows)+1, n, row)
+			}
+			rows = append(rows, row)
+			row = nil
+			i++
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if len(row) > 0 || cur.Len() > 0 {
+		return nil, fmt.Errorf("row %d is incomplete: %q", len(rows)+1, append(row, cur.String()))
+	}
+	return rows, nil
+}
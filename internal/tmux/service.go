@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -30,10 +31,12 @@ func (commandRunner) Run(ctx context.Context, args ...string) (string, error) {
 
 type Service struct {
 	runner Runner
+	// tty is the terminal a session attached from outside tmux is shown in.
+	tty string
 }
 
 func NewService(r Runner) *Service {
-	return &Service{runner: r}
+	return &Service{runner: r, tty: "/dev/tty"}
 }
 
 func (s *Service) DetectCurrentSession(ctx context.Context) (string, error) {
@@ -47,13 +50,78 @@ func (s *Service) DetectCurrentSession(ctx context.Context) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// ClientSize returns the size of the window area a restored window will be
+// shown in: the client tforge runs in, or outside tmux the terminal it will
+// attach from, less the status line. Both sizes are 0 when neither is known.
+func (s *Service) ClientSize(ctx context.Context) (width, height int, err error) {
+	if os.Getenv("TMUX") == "" {
+		rows, cols := s.terminalSize()
+		if rows <= 0 || cols <= 0 {
+			return 0, 0, nil
+		}
+		return cols, max(rows-s.statusLines(ctx), 1), nil
+	}
+	out, err := s.runner.Run(ctx, "display-message", "-p", "#{window_width} #{window_height}")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(strings.TrimSpace(out), "%d %d", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("unexpected tmux client size %q", out)
+	}
+	return width, height, nil
+}
+
+func (s *Service) terminalSize() (rows, cols int) {
+	tty, err := os.Open(s.tty)
+	if err != nil {
+		return 0, 0
+	}
+	defer tty.Close()
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0
+	}
+	if _, err := fmt.Sscanf(string(out), "%d %d", &rows, &cols); err != nil {
+		return 0, 0
+	}
+	return rows, cols
+}
+
+// statusLines is the height of the global status line; without a running
+// server it is tmux's default of one line.
+func (s *Service) statusLines(ctx context.Context) int {
+	out, err := s.runner.Run(ctx, "show-options", "-gv", "status")
+	if err != nil {
+		return 1
+	}
+	switch v := strings.TrimSpace(out); v {
+	case "off":
+		return 0
+	case "on":
+		return 1
+	default:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+		return 1
+	}
+}
+
 func (s *Service) ListSessions(ctx context.Context) ([]string, error) {
-	out, err := s.runner.Run(ctx, "list-sessions", "-F", "#{session_name}")
+	rows, err := query[SessionInfo](ctx, s.runner, "list-sessions")
 	if err != nil {
+		if isNoServer(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
-	lines := splitLines(out)
-	return lines, nil
+	names := make([]string, 0, len(rows))
+	for _, r := range rows {
+		names = append(names, r.Name)
+	}
+	return names, nil
 }
 
 func (s *Service) SessionExists(ctx context.Context, session string) (bool, error) {
@@ -69,12 +137,114 @@ func (s *Service) SessionExists(ctx context.Context, session string) (bool, erro
 	return false, nil
 }
 
-func (s *Service) ListWindows(ctx context.Context, session string) ([]string, error) {
-	return splitCommand(s.runner.Run(ctx, "list-windows", "-t", session, "-F", "#{window_index}|#{window_name}|#{window_layout}|#{window_active}"))
+func (s *Service) ListWindows(ctx context.Context, session string) ([]WindowInfo, error) {
+	return query[WindowInfo](ctx, s.runner, "list-windows", "-t", session)
+}
+
+func (s *Service) ListPanes(ctx context.Context, target string) ([]PaneInfo, error) {
+	return query[PaneInfo](ctx, s.runner, "list-panes", "-t", target)
+}
+
+func (s *Service) CapturePane(ctx context.Context, target string) (string, error) {
+	return s.runner.Run(ctx, "capture-pane", "-p", "-e", "-J", "-S", "-", "-t", target)
+}
+
+// SessionSize reports how many windows and panes a session currently has.
+func (s *Service) SessionSize(ctx context.Context, session string) (windows, panes int, err error) {
+	w, err := s.ListWindows(ctx, session)
+	if err != nil {
+		return 0, 0, err
+	}
+	p, err := query[PaneInfo](ctx, s.runner, "list-panes", "-s", "-t", session)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(w), len(p), nil
+}
+
+// NewSession creates a detached session and returns the ids of its first
+// window and pane. An empty command starts the default shell.
+func (s *Service) NewSession(ctx context.Context, session, window, dir, command string) (windowID, paneID string, err error) {
+	args := []string{"new-session", "-d", "-s", session, "-n", window, "-c", dir, "-P", "-F", "#{window_id} #{pane_id}"}
+	return splitIDs(s.runner.Run(ctx, withCommand(args, command)...))
+}
+
+// NewWindow appends a window to session and returns the ids of the window and
+// its first pane.
+func (s *Service) NewWindow(ctx context.Context, session, window, dir, command string) (windowID, paneID string, err error) {
+	args := []string{"new-window", "-d", "-t", session + ":", "-n", window, "-c", dir, "-P", "-F", "#{window_id} #{pane_id}"}
+	return splitIDs(s.runner.Run(ctx, withCommand(args, command)...))
+}
+
+// SplitWindow splits target and returns the id of the new pane.
+func (s *Service) SplitWindow(ctx context.Context, target, dir, command string) (string, error) {
+	args := []string{"split-window", "-d", "-t", target, "-c", dir, "-P", "-F", "#{pane_id}"}
+	out, err := s.runner.Run(ctx, withCommand(args, command)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (s *Service) SelectLayout(ctx context.Context, target, layout string) error {
+	_, err := s.runner.Run(ctx, "select-layout", "-t", target, layout)
+	return err
 }
 
-func (s *Service) ListPanes(ctx context.Context, target string) ([]string, error) {
-	return splitCommand(s.runner.Run(ctx, "list-panes", "-t", target, "-F", "#{pane_index}|#{pane_id}|#{pane_current_path}|#{pane_active}"))
+func (s *Service) SelectPane(ctx context.Context, target string) error {
+	_, err := s.runner.Run(ctx, "select-pane", "-t", target)
+	return err
+}
+
+func (s *Service) SelectWindow(ctx context.Context, target string) error {
+	_, err := s.runner.Run(ctx, "select-window", "-t", target)
+	return err
+}
+
+// SendKeys types text into target followed by Enter. The text is sent
+// literally so that a command such as "Enter" or "C-c" is not read as a key
+// name.
+func (s *Service) SendKeys(ctx context.Context, target, text string) error {
+	if _, err := s.runner.Run(ctx, "send-keys", "-l", "-t", target, "--", text); err != nil {
+		return err
+	}
+	_, err := s.runner.Run(ctx, "send-keys", "-t", target, "Enter")
+	return err
+}
+
+func (s *Service) KillSession(ctx context.Context, session string) error {
+	_, err := s.runner.Run(ctx, "kill-session", "-t", session)
+	return err
+}
+
+func (s *Service) KillWindow(ctx context.Context, target string) error {
+	_, err := s.runner.Run(ctx, "kill-window", "-t", target)
+	return err
+}
+
+func (s *Service) SwitchClient(ctx context.Context, session string) error {
+	_, err := s.runner.Run(ctx, "switch-client", "-t", session)
+	return err
+}
+
+// Attach connects the current terminal to session, switching the client when
+// already running inside tmux.
+func (s *Service) Attach(ctx context.Context, session string) error {
+	if os.Getenv("TMUX") != "" {
+		return s.SwitchClient(ctx, session)
+	}
+	cmd := exec.CommandContext(ctx, "tmux", "attach-session", "-t", session)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// UnbindKey drops a prefix-table binding. Reloading the config does not
+// remove bindings whose lines are gone, so deleting one needs this first.
+func (s *Service) UnbindKey(ctx context.Context, key string) error {
+	_, err := s.runner.Run(ctx, "unbind-key", key)
+	return err
 }
 
 func (s *Service) ReloadConfig(ctx context.Context, path string) error {
@@ -82,24 +252,50 @@ func (s *Service) ReloadConfig(ctx context.Context, path string) error {
 	return err
 }
 
-func splitCommand(out string, err error) ([]string, error) {
+// Version returns the tmux version, e.g. "3.3a".
+func (s *Service) Version(ctx context.Context) (string, error) {
+	out, err := s.runner.Run(ctx, "-V")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return splitLines(out), nil
+	return strings.TrimPrefix(strings.TrimSpace(out), "tmux "), nil
 }
 
-func splitLines(out string) []string {
-	if strings.TrimSpace(out) == "" {
-		return nil
+// VersionAtLeast reports whether a version string from Version is major.minor
+// or newer. Development builds ("next-3.4", "master") are assumed new enough.
+func VersionAtLeast(version string, major, minor int) bool {
+	v := strings.TrimPrefix(version, "next-")
+	if v == "master" {
+		return true
 	}
-	parts := strings.Split(strings.TrimSpace(out), "\n")
-	result := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			result = append(result, p)
-		}
+	var gotMajor, gotMinor int
+	if _, err := fmt.Sscanf(v, "%d.%d", &gotMajor, &gotMinor); err != nil {
+		return false
 	}
-	return result
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
+func withCommand(args []string, command string) []string {
+	if command == "" {
+		return args
+	}
+	return append(args, command)
+}
+
+func splitIDs(out string, err error) (string, string, error) {
+	if err != nil {
+		return "", "", err
+	}
+	windowID, paneID, ok := strings.Cut(strings.TrimSpace(out), " ")
+	if !ok {
+		return "", "", fmt.Errorf("unexpected tmux output %q", out)
+	}
+	return windowID, paneID, nil
+}
+
+// isNoServer reports whether err means no tmux server is running, which for
+// read-only queries is equivalent to having no sessions.
+func isNoServer(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no server running") || strings.Contains(msg, "error connecting to")
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -17,7 +19,7 @@ func (f fakeRunner) Run(_ context.Context, args ...string) (string, error) {
 
 func TestSessionExists(t *testing.T) {
 	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
-		return "hive\nops", nil
+		return "hive|\nops|", nil
 	}})
 	ok, err := svc.SessionExists(context.Background(), "hive")
 	if err != nil {
@@ -57,3 +59,121 @@ func TestDetectCurrentSession(t *testing.T) {
 		t.Fatalf("unexpected result: called=%v session=%q", called, s)
 	}
 }
+
+func TestListWindowsDecodesEscapedFields(t *testing.T) {
+	var gotArgs []string
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		gotArgs = args
+		return "@1|0|api\\|worker|a,b,c|1|\n@2|1|logs\\\\old|d,e,f|0|", nil
+	}})
+	windows, err := svc.ListWindows(context.Background(), "hive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format := gotArgs[len(gotArgs)-1]; format != "#{q:window_id}|#{q:window_index}|#{q:window_name}|#{q:window_layout}|#{q:window_active}|" {
+		t.Fatalf("unexpected format %q", format)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(windows))
+	}
+	if w := windows[0]; w.ID != "@1" || w.Name != "api|worker" || w.Layout != "a,b,c" || !w.Active {
+		t.Fatalf("unexpected first window: %+v", w)
+	}
+	if w := windows[1]; w.Index != 1 || w.Name != `logs\old` || w.Active {
+		t.Fatalf("unexpected second window: %+v", w)
+	}
+}
+
+func TestListPanesKeepsNewlinesInFields(t *testing.T) {
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		return "0|%1|/tmp/a\nb|1|100|bash|\nvim\n|\n1|%2|/repo|0|101|top||", nil
+	}})
+	panes, err := svc.ListPanes(context.Background(), "@1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(panes) != 2 || panes[0].Path != "/tmp/a\nb" || panes[0].StartCommand != "\nvim\n" || panes[1].ID != "%2" {
+		t.Fatalf("unexpected panes: %+v", panes)
+	}
+}
+
+func TestListPanesRejectsMalformedRow(t *testing.T) {
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		return "0|%1|/repo|", nil
+	}})
+	if _, err := svc.ListPanes(context.Background(), "@1"); err == nil {
+		t.Fatal("expected error for a row with missing fields")
+	}
+}
+
+func TestSendKeysTypesTextLiterally(t *testing.T) {
+	var calls [][]string
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		calls = append(calls, args)
+		return "", nil
+	}})
+	if err := svc.SendKeys(context.Background(), "%1", "C-c"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"send-keys", "-l", "-t", "%1", "--", "C-c"}, {"send-keys", "-t", "%1", "Enter"}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("got calls %q, want %q", calls, want)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		if len(args) != 1 || args[0] != "-V" {
+			t.Fatalf("unexpected args: %v", args)
+		}
+		return "tmux 3.3a", nil
+	}})
+	v, err := svc.Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "3.3a" {
+		t.Fatalf("unexpected version %q", v)
+	}
+	for v, want := range map[string]bool{"3.3a": true, "2.9": true, "2.8": false, "next-3.5": true, "master": true, "openbsd-7.4": false} {
+		if got := VersionAtLeast(v, 2, 9); got != want {
+			t.Fatalf("VersionAtLeast(%q, 2, 9) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestClientSize(t *testing.T) {
+	t.Setenv("TMUX", "")
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		return "158 37", nil
+	}})
+	svc.tty = filepath.Join(t.TempDir(), "missing")
+	if w, h, err := svc.ClientSize(context.Background()); err != nil || w != 0 || h != 0 {
+		t.Fatalf("expected no size without a terminal, got %dx%d %v", w, h, err)
+	}
+	t.Setenv("TMUX", "1")
+	w, h, err := svc.ClientSize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != 158 || h != 37 {
+		t.Fatalf("unexpected size %dx%d", w, h)
+	}
+}
+
+func TestStatusLines(t *testing.T) {
+	for out, want := range map[string]int{"on": 1, "off": 0, "2": 2, "bogus": 1} {
+		svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+			return out, nil
+		}})
+		if got := svc.statusLines(context.Background()); got != want {
+			t.Errorf("status %q: got %d lines, want %d", out, got, want)
+		}
+	}
+	svc := NewService(fakeRunner{fn: func(args ...string) (string, error) {
+		return "", errors.New("no server running")
+	}})
+	if got := svc.statusLines(context.Background()); got != 1 {
+		t.Errorf("without a server: got %d lines, want 1", got)
+	}
+}